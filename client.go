@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"math/big"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -20,6 +21,7 @@ import (
 	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/ivanzzeth/ethclient/account"
 	"github.com/ivanzzeth/ethclient/common/consts"
+	"github.com/ivanzzeth/ethclient/ds/semaphore"
 	"github.com/ivanzzeth/ethclient/message"
 	"github.com/ivanzzeth/ethclient/nonce"
 	"github.com/ivanzzeth/ethclient/subscriber"
@@ -67,11 +69,18 @@ type Client struct {
 	receiptChannel  chan message.Receipt
 
 	accRegistry  account.Registry
-	msgStore     message.Storage
+	msgStore     *message.ObservedStorage
 	nonceManager nonce.Manager
+	gapChecker   *nonce.GapChecker
 	msgManager   message.Manager
 	msgSequencer message.Sequencer
 	broadcaster  message.Broadcaster
+	msgLocks     sync.Map
+	laneLimiter  *semaphore.Semaphore
+	lanes        *broadcastLanes
+
+	lowBalanceLock      sync.RWMutex
+	lowBalanceObservers []message.LowBalanceObserver
 
 	subscriber.Subscriber
 }
@@ -128,6 +137,12 @@ func NewEthClient(
 ) (*Client, error) {
 	ethc := ethclient.NewClient(c)
 
+	observedStore := message.NewObservedStorage(msgStore)
+	if m, ok := msgManager.(*message.SimpleManager); ok {
+		// msgs updated by the manager are observed as well
+		m.Storage = observedStore
+	}
+
 	cli := &Client{
 		Client:          ethc,
 		gethClient:      &gethClient{Client: gethclient.New(c)},
@@ -138,14 +153,20 @@ func NewEthClient(
 		respChannel:     make(chan message.Response, consts.DefaultMsgBuffer),
 		receiptChannel:  make(chan message.Receipt, consts.DefaultMsgBuffer),
 		msgBuffer:       consts.DefaultMsgBuffer,
-		msgStore:        msgStore,
+		msgStore:        observedStore,
 		msgSequencer:    sequencer,
 		nonceManager:    nonceManager,
+		gapChecker:      nonce.NewGapChecker(ethc, nonceManager, accRegistry.GetSigner()),
 		msgManager:      msgManager,
 		broadcaster:     message.NewSimpleBroadcaster(msgManager),
+		laneLimiter:     semaphore.NewSemaphore(consts.DefaultBroadcastConcurrency),
+		lanes:           newBroadcastLanes(),
 		Subscriber:      subscriber,
 	}
 
+	// msgs of busy senders wait in the sequencer
+	sequencer.SetGovernor(cli.lanes)
+
 	go cli.sendMsgTask(context.Background())
 
 	return cli, nil
@@ -169,6 +190,12 @@ func (c *Client) Close() {
 
 	c.CloseSendMsg()
 
+	c.gapChecker.Close()
+
+	if b, ok := c.broadcaster.(*message.SimpleBroadcaster); ok {
+		b.Close()
+	}
+
 	c.Client.Close()
 
 	log.Debug("underlying ethclient closed")
@@ -198,6 +225,12 @@ func (c *Client) RpcClient() *rpc.Client {
 
 func (c *Client) SetNonceManager(nm nonce.Manager) {
 	c.nonceManager = nm
+	c.gapChecker.SetNonceManager(nm)
+}
+
+// NonceGapChecker returns the checker filling nonce gaps of accounts sending msgs.
+func (c *Client) NonceGapChecker() *nonce.GapChecker {
+	return c.gapChecker
 }
 
 func (c *Client) SetSubscriber(s subscriber.Subscriber) {
@@ -221,11 +254,123 @@ func (c *Client) SetMsgBuffer(buffer int) {
 	c.msgBuffer = buffer
 }
 
+// AddMsgObserver registers the observer notified of every update on status, response and receipt of msgs.
+func (c *Client) AddMsgObserver(observer message.MessageObserver) {
+	c.msgStore.AddObserver(observer)
+}
+
+// SetGasEstimator replaces the nonce manager estimating gas limits of msgs without Gas,
+// e.g. gas.LimitEstimator, which is also added as an observer if it learns from msgs.
+func (c *Client) SetGasEstimator(estimator message.GasEstimator) {
+	m, ok := c.msgManager.(*message.SimpleManager)
+	if !ok {
+		log.Warn("gas estimator is not supported by the msg manager")
+		return
+	}
+	m.SetGasEstimator(estimator)
+
+	if observer, ok := estimator.(message.MessageObserver); ok {
+		c.AddMsgObserver(observer)
+	}
+}
+
+// SetSenderPool sets accounts of the sender pool, msgs with the Pool are sent by the account
+// with the fewest msgs in flight among those could cover them. The pool is removed if no accounts were given.
+// The sender is picked before msgs are queued, so that guards, governors and lanes see the account,
+// and picked again right before the nonce is assigned if the account could no longer cover the msg or another one is less loaded.
+func (c *Client) SetSenderPool(name string, accounts ...common.Address) {
+	m, ok := c.msgManager.(*message.SimpleManager)
+	if !ok {
+		log.Warn("sender pools are not supported by the msg manager", "pool", name)
+		return
+	}
+	m.SetSenderPool(name, accounts...)
+}
+
+// senderPool returns accounts of the pool, nil if it doesn't exist.
+func (c *Client) senderPool(name string) []common.Address {
+	m, ok := c.msgManager.(*message.SimpleManager)
+	if !ok {
+		return nil
+	}
+
+	return m.SenderPool(name)
+}
+
+// assignSender picks the sender of the msg from its pool.
+func (c *Client) assignSender(ctx context.Context, msg message.Request) (message.Request, error) {
+	m, ok := c.msgManager.(*message.SimpleManager)
+	if !ok {
+		return msg, fmt.Errorf("%w: %v", message.ErrUnknownSenderPool, msg.Pool)
+	}
+
+	return m.AssignSender(ctx, msg)
+}
+
+// reassignSender picks the sender of the msg from its pool again right before its nonce is assigned.
+func (c *Client) reassignSender(ctx context.Context, msg message.Request) (message.Request, bool, error) {
+	m, ok := c.msgManager.(*message.SimpleManager)
+	if !ok {
+		return msg, false, fmt.Errorf("%w: %v", message.ErrUnknownSenderPool, msg.Pool)
+	}
+
+	return m.ReassignSender(ctx, msg)
+}
+
+// SetGovernor limits the throughput of msgs broadcasted, e.g. message.ThroughputGovernor,
+// which is also added as an observer if it counts msgs by their status.
+// Msgs over the limits wait in the sequencer.
+func (c *Client) SetGovernor(governor message.Governor) {
+	c.lanes.setGovernor(governor)
+
+	if observer, ok := governor.(message.MessageObserver); ok {
+		c.AddMsgObserver(observer)
+	}
+}
+
+// AddLowBalanceObserver registers the observer notified of msgs held for insufficient funds of their senders.
+func (c *Client) AddLowBalanceObserver(observer message.LowBalanceObserver) {
+	c.lowBalanceLock.Lock()
+	defer c.lowBalanceLock.Unlock()
+
+	c.lowBalanceObservers = append(c.lowBalanceObservers, observer)
+}
+
+// SetBroadcastConcurrency limits how many senders broadcast msgs at the same time, no limit if it's not positive.
+// Msgs of the same sender are always broadcasted one by one.
+func (c *Client) SetBroadcastConcurrency(concurrency int) {
+	c.laneLimiter.SetLimit(concurrency)
+}
+
+// SetFinalityDepth sets how many blocks on top of on-chain msgs they are final,
+// it's used on chains without finality tags, message.DefaultFinalityDepth by default.
+func (c *Client) SetFinalityDepth(depth uint64) {
+	if b, ok := c.broadcaster.(*message.SimpleBroadcaster); ok {
+		b.SetFinalityDepth(depth)
+	}
+}
+
+// SetFinalityTag sets the block tag for finalizing on-chain msgs, rpc.FinalizedBlockNumber by default.
+// Use rpc.LatestBlockNumber for finalizing msgs by the finality depth only.
+func (c *Client) SetFinalityTag(tag rpc.BlockNumber) {
+	if b, ok := c.broadcaster.(*message.SimpleBroadcaster); ok {
+		b.SetFinalityTag(tag)
+	}
+}
+
 func (c *Client) NewMethodData(a abi.ABI, methodName string, args ...interface{}) ([]byte, error) {
 	return a.Pack(methodName, args...)
 }
 
 func (c *Client) ScheduleMsg(req *message.Request) {
+	if req.IdempotencyKey != "" {
+		_, err := c.SubmitMsg(req)
+		if err != nil {
+			log.Error("submit message failed", "msgId", req.Id().Hex(), "err", err)
+		}
+		return
+	}
+
 	log.Info("schedule message", "msgId", req.Id().Hex())
 	if c.reqClosed.Load() {
 		// TODO: return error
@@ -234,6 +379,56 @@ func (c *Client) ScheduleMsg(req *message.Request) {
 	c.reqChannel <- *req.Copy()
 }
 
+// SubmitMsg adds the msg to storage before scheduling it, and returns the msg stored.
+// If the request has an IdempotencyKey, its id is derived from the key and From,
+// and the existing msg is returned instead of being sent again once the key was submitted before.
+func (c *Client) SubmitMsg(req *message.Request) (message.Message, error) {
+	if c.reqClosed.Load() {
+		return message.Message{}, errors.New("client is closed")
+	}
+
+	if req.IdempotencyKey != "" {
+		req.SetIdByIdempotencyKey()
+	}
+
+	if req.Id() == (common.Hash{}) {
+		return message.Message{}, fmt.Errorf("no msgId provided")
+	}
+
+	log.Info("submit message", "msgId", req.Id().Hex(), "idempotencyKey", req.IdempotencyKey)
+
+	err := c.msgStore.AddMsg(*req.Copy())
+	if err != nil {
+		if req.IdempotencyKey == "" {
+			return message.Message{}, err
+		}
+
+		msg, getErr := c.msgStore.GetMsg(req.Id())
+		if getErr != nil {
+			return message.Message{}, err
+		}
+
+		log.Info("message with the same idempotency key was submitted", "msgId", req.Id().Hex(), "status", msg.Status)
+		return msg, nil
+	}
+
+	msg, err := c.msgStore.GetMsg(req.Id())
+	if err != nil {
+		return message.Message{}, err
+	}
+
+	if req.Recurring() {
+		// the series could be paused or stopped right after submitted
+		err = c.registerSeries(req.Id())
+		if err != nil {
+			return message.Message{}, err
+		}
+	}
+
+	c.reqChannel <- *req.Copy()
+	return msg, nil
+}
+
 func (c *Client) ReplayMsg(msgId common.Hash) (newMsgId common.Hash, err error) {
 	if c.reqClosed.Load() {
 		return common.Hash{}, errors.New("client is closed")
@@ -245,6 +440,8 @@ func (c *Client) ReplayMsg(msgId common.Hash) (newMsgId common.Hash, err error)
 	}
 
 	copiedReq := msg.Req.CopyWithoutId()
+	// replaying is sending it again on purpose
+	copiedReq.IdempotencyKey = ""
 
 	message.AssignMessageId(copiedReq)
 
@@ -254,6 +451,68 @@ func (c *Client) ReplayMsg(msgId common.Hash) (newMsgId common.Hash, err error)
 	return
 }
 
+// CancelMsg stops the message from being executed.
+// The message will be removed from the pipeline if it's not broadcasted yet,
+// otherwise it will be replaced by a zero-value self-transfer with the same nonce.
+// The message is marked as MessageStatusCancelled once the cancellation lands.
+func (c *Client) CancelMsg(msgId common.Hash) error {
+	locker := c.msgLock(msgId)
+	locker.Lock()
+	defer locker.Unlock()
+
+	msg, err := c.msgStore.GetMsg(msgId)
+	if err != nil {
+		return err
+	}
+
+	switch msg.Status {
+	case message.MessageStatusSubmitted, message.MessageStatusScheduled, message.MessageStatusQueued,
+		message.MessageStatusWaitingForFunds:
+		// another process may have broadcasted it meanwhile
+		err = c.msgStore.TransitMsgStatus(msgId, []message.MessageStatus{message.MessageStatusSubmitted,
+			message.MessageStatusScheduled, message.MessageStatusQueued, message.MessageStatusWaitingForFunds},
+			message.MessageStatusCancelled)
+		if err != nil {
+			return err
+		}
+
+		resp := message.Response{Id: msgId, Err: message.ErrMsgCancelled}
+		err = c.msgStore.UpdateResponse(msgId, resp)
+		if err != nil {
+			return err
+		}
+
+		if !c.reqClosed.Load() {
+			c.respChannel <- resp
+		}
+
+		return nil
+	case message.MessageStatusNonceAssigned, message.MessageStatusInflight:
+		if msg.Receipt != nil {
+			return fmt.Errorf("msg already on-chain")
+		}
+
+		if msg.Resp == nil {
+			return fmt.Errorf("msg is being broadcasted")
+		}
+
+		if msg.Resp.Err != nil {
+			// never broadcasted
+			return c.msgStore.TransitMsgStatus(msgId, []message.MessageStatus{msg.Status}, message.MessageStatusCancelled)
+		}
+
+		resp := c.broadcaster.CancelMsg(context.Background(), msgId)
+		return resp.Err
+	default:
+		return fmt.Errorf("msg with status %v can not be cancelled", msg.Status)
+	}
+}
+
+func (c *Client) msgLock(msgId common.Hash) sync.Locker {
+	lock, _ := c.msgLocks.LoadOrStore(msgId, &sync.Mutex{})
+	return lock.(*sync.Mutex)
+}
+
 func (c *Client) Response() <-chan message.Response {
 	return c.respChannel
 }
@@ -271,6 +530,8 @@ func (c *Client) sendMsgTask(ctx context.Context) {
 	go c.sequence()
 
 	go c.broadcast(ctx)
+
+	go c.gapChecker.Run()
 }
 
 func (c *Client) schedule() {
@@ -308,16 +569,42 @@ func (c *Client) schedule() {
 				return
 			}
 
+			if msg.Status == message.MessageStatusCancelled {
+				log.Debug("scheduler drops cancelled msg", "msgId", msg.Id().Hex())
+				return
+			}
+
 			now := time.Now().UnixNano()
 
-			if msg.Req.Interval != 0 {
+			if msg.Req.Recurring() {
 				if msg.Req.StartTime == 0 {
-					msg.Req.StartTime = now
+					var ok bool
+					msg.Req.StartTime, ok, err = msg.Req.FirstRun(now)
+					if err != nil {
+						return
+					}
+
+					if !ok {
+						err = c.msgStore.UpdateMsgStatus(req.Id(), message.MessageStatusExpired)
+						if err != nil {
+							return
+						}
+
+						err = fmt.Errorf("recurring task was over before its first run")
+						return
+					}
 				}
-				err = c.msgStore.UpdateMsg(msg)
+				err = c.msgStore.UpdateRequest(msg.Id(), *msg.Req)
 				if err != nil {
 					return
 				}
+
+				if msg.Root == nil {
+					err = c.registerSeries(msg.Id())
+					if err != nil {
+						return
+					}
+				}
 			}
 
 			if msg.Req.ExpirationTime != 0 && msg.Req.ExpirationTime < now {
@@ -328,6 +615,7 @@ func (c *Client) schedule() {
 				}
 
 				err = fmt.Errorf("timeout")
+				return
 			}
 
 			req = *msg.Req
@@ -346,19 +634,88 @@ func (c *Client) schedule() {
 				return
 			}
 
-			c.scheduleChannel <- *msg.Req
+			seriesStatus, err := c.seriesStatus(msg)
+			if err != nil {
+				return
+			}
 
+			if seriesStatus == message.SeriesStatusStopped {
+				log.Debug("scheduler cancels msg of stopped series", "msg", msg.Id().Hex())
+				err = c.msgStore.UpdateMsgStatus(req.Id(), message.MessageStatusCancelled)
+				if err != nil {
+					return
+				}
+
+				err = message.ErrSeriesStopped
+				return
+			}
+
+			if seriesStatus == message.SeriesStatusPaused {
+				log.Debug("scheduler found series of the msg paused", "msg", msg.Id().Hex())
+				go func() {
+					time.Sleep(consts.SeriesCheckInterval)
+					if !c.reqClosed.Load() {
+						c.reqChannel <- *msg.Req
+					} else {
+						log.Warn("ethclient closed, then drop the request", "msg", msg.Id().Hex())
+					}
+				}()
+				return
+			}
+
+			ready, err := c.checkDependencies(context.Background(), req)
+			if errors.Is(err, message.ErrDependencyFailed) {
+				log.Debug("scheduler skips msg with broken dependency", "msg", msg.Id().Hex(), "err", err)
+				if err := c.msgStore.UpdateMsgStatus(req.Id(), message.MessageStatusSkipped); err != nil {
+					log.Error("update status of skipped msg failed", "msg", msg.Id().Hex(), "err", err)
+				}
+				return
+			}
+
+			if err != nil || !ready {
+				log.Debug("scheduler found dependencies of the msg not met", "msg", msg.Id().Hex(), "err", err)
+				err = nil
+				go func() {
+					time.Sleep(consts.DependencyCheckInterval)
+					if !c.reqClosed.Load() {
+						c.reqChannel <- *msg.Req
+					} else {
+						log.Warn("ethclient closed, then drop the request", "msg", msg.Id().Hex())
+					}
+				}()
+				return
+			}
+
+			// the status is updated first, so that it's not overwritten once the msg was sequenced
 			err = c.msgStore.UpdateMsgStatus(req.Id(), message.MessageStatusScheduled)
 			if err != nil {
 				err = fmt.Errorf("no msgId provided")
 				return
 			}
 
-			if msg.Req.Interval != 0 {
+			c.scheduleChannel <- *msg.Req
+
+			if msg.Req.Recurring() {
+				var (
+					next int64
+					more bool
+				)
+				next, more, err = req.NextRun(now)
+				if err != nil || !more {
+					log.Debug("recurring task is over", "msg", msg.Id().Hex(), "err", err)
+					return
+				}
+
 				newReq := req.CopyWithoutId()
 
 				newReq.AfterMsg = nil
-				newReq.StartTime = now + int64(req.Interval)
+				newReq.AfterMsgs = nil
+				newReq.AfterConditions = nil
+				newReq.IdempotencyKey = ""
+				newReq.StartTime = next
+				if newReq.MaxRuns > 0 {
+					newReq.MaxRuns--
+				}
 
 				message.AssignMessageId(newReq)
 				log.Debug("scheduler creates new one for long-term ticker task", "msg", msg.Id().Hex(), "new_msg", newReq.Id().Hex())
@@ -416,6 +773,26 @@ func (c *Client) sequence() {
 					c.respChannel <- resp
 				}
 			}()
+
+			// msgs pushed back are assigned again, e.g. held for funds of the account assigned
+			if msg.Pool != "" {
+				msg, err = c.assignSender(context.Background(), msg)
+
+				var fundsErr *message.FundsError
+				if errors.As(err, &fundsErr) {
+					err = nil
+					if !c.holdForFunds(context.Background(), msg, fundsErr.LowBalance) {
+						c.msgSequencer.AckMsg(msg.Id())
+					}
+					return
+				}
+				if err != nil {
+					// it may be popped before
+					c.msgSequencer.AckMsg(msg.Id())
+					return
+				}
+			}
+
 			err = c.msgSequencer.PushMsg(msg)
 			if err != nil {
 				return
@@ -433,50 +810,200 @@ func (c *Client) sequence() {
 	c.msgSequencer.Close()
 }
 
+// broadcast dispatches msgs to lanes of their senders, so that a slow sender doesn't hold up others.
+// Msgs of busy senders are held in the sequencer, so that priority is decided once their lane is free.
 func (c *Client) broadcast(ctx context.Context) {
-	for {
-		exit := func() (exit bool) {
-			var err error
+	var wg sync.WaitGroup
 
-			msg, err := c.msgSequencer.PopMsg()
-			if err != nil {
-				if errors.Is(err, message.ErrPendingChannelClosed) {
-					log.Debug("close responseChannel...")
-					close(c.respChannel)
-					close(c.receiptChannel)
-					return true
-				}
-				log.Error("unexpected broadcast case", "err", err)
-				return true
+	for {
+		msg, err := c.msgSequencer.PopMsg()
+		if err != nil {
+			// lanes exit once they are drained
+			wg.Wait()
+
+			if errors.Is(err, message.ErrPendingChannelClosed) {
+				log.Debug("close responseChannel...")
+				close(c.respChannel)
+				close(c.receiptChannel)
+				return
 			}
+			log.Error("unexpected broadcast case", "err", err)
+			return
+		}
 
-			var resp message.Response
-			resp.Id = msg.Id()
-			defer func() {
-				log.Debug("Client.broadcast UpdateResponse", "resp", resp, "msgId", msg.Id())
+		if c.lanes.enqueue(msg) {
+			log.Debug("open broadcast lane", "from", msg.From.Hex())
 
-				c.msgStore.UpdateResponse(resp.Id, resp)
-				c.respChannel <- resp
-			}()
+			wg.Add(1)
+			go func(from common.Address) {
+				defer wg.Done()
+				c.runLane(ctx, from)
+			}(msg.From)
+		}
+	}
+}
 
-			if msg.SimulationOn {
-				resp = c.msgManager.CallMsg(ctx, msg, nil)
-			}
+// runLane broadcasts msgs of one sender in order, until the lane is idle.
+func (c *Client) runLane(ctx context.Context, from common.Address) {
+	for {
+		msg, ok := c.lanes.next(from)
+		if !ok {
+			log.Debug("close idle broadcast lane", "from", from.Hex())
+			return
+		}
+
+		c.laneLimiter.Acquire()
+		c.broadcastMsg(ctx, msg)
+		c.laneLimiter.Release()
+	}
+}
+
+var _ message.Governor = (*broadcastLanes)(nil)
+
+// broadcastLanes tracks lanes of senders being broadcasted, and holds other msgs of them in the sequencer.
+type broadcastLanes struct {
+	lock     sync.Mutex
+	lanes    map[common.Address]*broadcastLane
+	governor message.Governor
+}
+
+// broadcastLane queues msgs of one sender, only those popped before the lane was opened wait here.
+type broadcastLane struct {
+	queue []message.Request
+}
+
+func newBroadcastLanes() *broadcastLanes {
+	return &broadcastLanes{
+		lanes: make(map[common.Address]*broadcastLane),
+	}
+}
+
+// Allow holds msgs of senders with an open lane, and limits others by the governor if set.
+func (l *broadcastLanes) Allow(req message.Request) bool {
+	l.lock.Lock()
+	_, busy := l.lanes[req.From]
+	governor := l.governor
+	l.lock.Unlock()
+
+	if busy {
+		return false
+	}
+
+	return governor == nil || governor.Allow(req)
+}
+
+func (l *broadcastLanes) setGovernor(governor message.Governor) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	l.governor = governor
+}
+
+// enqueue returns true if the lane of the sender was opened for the msg.
+func (l *broadcastLanes) enqueue(msg message.Request) (opened bool) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	lane, ok := l.lanes[msg.From]
+	if !ok {
+		lane = &broadcastLane{}
+		l.lanes[msg.From] = lane
+	}
+	lane.queue = append(lane.queue, msg)
+
+	return !ok
+}
 
-			if resp.Err == nil {
-				sendResp := c.broadcaster.SendMsg(ctx, msg)
-				log.Debug("broadcaster.SendMsg resp", "resp", sendResp)
-				resp.Id = sendResp.Id
-				resp.Err = sendResp.Err
-				resp.Tx = sendResp.Tx
+// next returns the next msg of the sender, the lane is closed if there is none.
+func (l *broadcastLanes) next(from common.Address) (msg message.Request, ok bool) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	lane := l.lanes[from]
+	if len(lane.queue) == 0 {
+		delete(l.lanes, from)
+		return message.Request{}, false
+	}
+
+	msg = lane.queue[0]
+	lane.queue[0] = message.Request{}
+	lane.queue = lane.queue[1:]
+
+	return msg, true
+}
+
+func (c *Client) broadcastMsg(ctx context.Context, msg message.Request) {
+	// msgs pushed back to the sequencer are not acked, so that their dependants are held as well
+	requeued := false
+	defer func() {
+		if !requeued {
+			c.msgSequencer.AckMsg(msg.Id())
+		}
+	}()
+
+	locker := c.msgLock(msg.Id())
+	locker.Lock()
+	defer locker.Unlock()
+
+	if storedMsg, err := c.msgStore.GetMsg(msg.Id()); err == nil && storedMsg.Status == message.MessageStatusCancelled {
+		log.Debug("broadcaster drops cancelled msg", "msgId", msg.Id().Hex())
+		return
+	}
+
+	// funds and load of the pool change while the msg is queued, so it goes out on the lane of the sender picked again
+	if msg.Pool != "" {
+		reassigned, moved, err := c.reassignSender(ctx, msg)
+		if err != nil {
+			log.Warn("reassign sender failed, then keep the sender", "msgId", msg.Id().Hex(), "err", err)
+		} else if moved {
+			err = c.msgSequencer.PushMsg(reassigned)
+			if err == nil {
+				requeued = true
+				return
 			}
 
+			// the sender was recorded already, then send it here
+			log.Warn("push reassigned msg failed", "msgId", msg.Id().Hex(), "err", err)
+			msg = reassigned
+		}
+	}
+
+	if msg.Guard != nil {
+		var met bool
+		met, requeued = c.checkGuard(ctx, msg)
+		if !met {
 			return
-		}()
+		}
+	}
 
-		if exit {
+	var resp message.Response
+	resp.Id = msg.Id()
+	defer func() {
+		var fundsErr *message.FundsError
+		if errors.As(resp.Err, &fundsErr) {
+			requeued = c.holdForFunds(ctx, msg, fundsErr.LowBalance)
 			return
 		}
+
+		log.Debug("Client.broadcast UpdateResponse", "resp", resp, "msgId", msg.Id())
+
+		c.msgStore.UpdateResponse(resp.Id, resp)
+		c.respChannel <- resp
+	}()
+
+	c.gapChecker.Watch(msg.From)
+
+	if msg.SimulationOn {
+		resp.Simulation, resp.Err = c.simulateMsg(ctx, msg)
+		resp.ReturnData = resp.Simulation.ReturnData
+	}
+
+	if resp.Err == nil {
+		sendResp := c.broadcaster.SendMsg(ctx, msg)
+		log.Debug("broadcaster.SendMsg resp", "resp", sendResp)
+		resp.Id = sendResp.Id
+		resp.Err = sendResp.Err
+		resp.Tx = sendResp.Tx
 	}
 }
 
@@ -500,6 +1027,11 @@ func (c *Client) WaitMsgReceipt(msgId common.Hash, confirmations uint64, timeout
 	return c.msgManager.WaitMsgReceipt(msgId, confirmations, timeout)
 }
 
+// WaitMsgFinalized waits until the msg could no longer be reverted by reorgs.
+func (c *Client) WaitMsgFinalized(msgId common.Hash, timeout time.Duration) (*message.Receipt, bool) {
+	return c.msgManager.WaitMsgFinalized(msgId, timeout)
+}
+
 // MessageToTransactOpts .
 // NOTE: You must provide private key for signature.
 func (c *Client) MessageToTransactOpts(ctx context.Context, msg message.Request) (*bind.TransactOpts, error) {
@@ -575,6 +1107,10 @@ func (c *Client) GetNonce(msgId common.Hash) (uint64, error) {
 	return c.msgStore.GetNonce(msgId)
 }
 
+func (c *Client) ListMsgs(filter message.MsgFilter) (msgs []message.Message, nextCursor string, err error) {
+	return c.msgStore.ListMsgs(filter)
+}
+
 func (c *Client) DebugTransactionOnChain(ctx context.Context, txHash common.Hash) ([]byte, error) {
 	receipt, confirmed := c.WaitTxReceipt(txHash, 3, 30*time.Second)
 	if !confirmed {
@@ -7,4 +7,10 @@ const (
 	DefaultMsgBuffer     = 1000
 	DefaultBlocksPerScan = uint64(100)
 	MaxBlocksPerScan     = uint64(10000000)
+
+	DefaultNonceGapCheckInterval = 30 * time.Second
+	DefaultBroadcastConcurrency  = 16
+	DependencyCheckInterval      = time.Second
+	SeriesCheckInterval          = time.Second
+	FundsCheckInterval           = time.Second
 )
@@ -0,0 +1,49 @@
+package cron
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNext(t *testing.T) {
+	shanghai, err := time.LoadLocation("Asia/Shanghai")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	from := time.Date(2024, 1, 31, 13, 5, 30, 0, time.UTC)
+
+	testcases := []struct {
+		expr string
+		loc  *time.Location
+		want time.Time
+	}{
+		{"0 */4 * * *", nil, time.Date(2024, 1, 31, 16, 0, 0, 0, time.UTC)},
+		{"*/15 * * * *", nil, time.Date(2024, 1, 31, 13, 15, 0, 0, time.UTC)},
+		{"30 9 * * mon-fri", nil, time.Date(2024, 2, 1, 9, 30, 0, 0, time.UTC)},
+		{"0 0 29 feb *", nil, time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1,15 * 7", nil, time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{"@daily", nil, time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 9 * * *", shanghai, time.Date(2024, 2, 1, 9, 0, 0, 0, shanghai)},
+		{"0 0 30 feb *", nil, time.Time{}},
+	}
+
+	for _, tt := range testcases {
+		s, err := Parse(tt.expr, tt.loc)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got := s.Next(from)
+		assert.True(t, tt.want.Equal(got), "%v: want %v, got %v", tt.expr, tt.want, got)
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, expr := range []string{"* * * *", "60 * * * *", "* * * * 8", "*/0 * * * *", "5-1 * * * *", "a * * * *"} {
+		_, err := Parse(expr, nil)
+		assert.Error(t, err, expr)
+	}
+}
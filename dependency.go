@@ -0,0 +1,43 @@
+package ethclient
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ivanzzeth/ethclient/message"
+)
+
+// checkDependencies reports whether conditions of all AfterMsgs were met,
+// err is message.ErrDependencyFailed if any of them could never be met.
+func (c *Client) checkDependencies(ctx context.Context, req message.Request) (ready bool, err error) {
+	var blockNumber uint64
+	ready = true
+
+	for _, predId := range req.AfterMsgs {
+		pred, err := c.msgStore.GetMsg(predId)
+		if err != nil {
+			// not submitted yet
+			ready = false
+			continue
+		}
+
+		cond := req.AfterConditions[predId]
+		if cond.Kind == message.ConditionSuccess && cond.Confirmations > 0 && blockNumber == 0 {
+			blockNumber, err = c.Client.BlockNumber(ctx)
+			if err != nil {
+				return false, err
+			}
+		}
+
+		met, broken := cond.Check(pred, blockNumber)
+		if broken {
+			return false, fmt.Errorf("%w: msg %v", message.ErrDependencyFailed, predId.Hex())
+		}
+
+		if !met {
+			ready = false
+		}
+	}
+
+	return ready, nil
+}
@@ -1,6 +1,8 @@
 package graph
 
 import (
+	"slices"
+	"sort"
 	"sync"
 	"time"
 
@@ -12,6 +14,9 @@ type DiGraph struct {
 	inDegree  map[interface{}]int
 	queue     []interface{}
 	isInQueue map[interface{}]bool
+	seq       map[interface{}]uint64 // order of vertices added, ready vertices are popped in this order by default
+	nextSeq   uint64
+	less      func(a, b interface{}) bool
 	buffer    int
 	mutex     sync.Mutex
 }
@@ -21,10 +26,29 @@ func NewDirectedGraph(buffer int) *DiGraph {
 		graph:     make(map[interface{}]map[interface{}]int),
 		inDegree:  make(map[interface{}]int),
 		isInQueue: make(map[interface{}]bool),
+		seq:       make(map[interface{}]uint64),
 		buffer:    buffer,
 	}
 }
 
+// SetLess sets the order of ready vertices, less reports whether a should be popped before b.
+// Vertices are popped in order of being added if less is nil or reports neither is less.
+func (g *DiGraph) SetLess(less func(a, b interface{}) bool) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	g.less = less
+}
+
+// ReadyCount returns how many vertices could be popped now.
+func (g *DiGraph) ReadyCount() int {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	g.collectReady()
+	return len(g.queue)
+}
+
 func (g *DiGraph) QueuedCount() int {
 	return len(g.inDegree)
 }
@@ -41,6 +65,7 @@ func (g *DiGraph) AddVertex(v interface{}) {
 	if _, ok := g.inDegree[v]; !ok {
 		g.inDegree[v] = 0
 	}
+	g.addSeq(v)
 }
 
 func (g *DiGraph) AddEdge(from, to interface{}) {
@@ -58,6 +83,8 @@ func (g *DiGraph) AddEdge(from, to interface{}) {
 		}
 		g.inDegree[to]++
 	}
+	g.addSeq(from)
+	g.addSeq(to)
 
 	log.Debug("AddEdge", "from", from, "to", to, "fromInDegree", g.inDegree[from], "toInDegree", g.inDegree[to])
 }
@@ -77,65 +104,114 @@ func (g *DiGraph) delEdge(from, to interface{}) {
 	}
 }
 
-// vertex will be deleted after consuming
-func (g *DiGraph) Pipeline() <-chan interface{} {
-	output := make(chan interface{}, g.buffer)
+// Pop returns the first ready vertex without blocking,
+// and the vertex is deleted, so that its neighbours may be ready.
+func (g *DiGraph) Pop() (vertex interface{}, ok bool) {
+	vertex, ok = g.Take()
+	if ok {
+		g.Done(vertex)
+	}
 
-	go func() {
-		for {
-			// log.Debug("read from inDegree starts")
-			g.mutex.Lock()
-			for vertex, inDegree := range g.inDegree {
-				// log.Debug("read from inDegree handling", "vertex", vertex, "indegree", inDegree)
-				if inDegree == 0 {
-					// log.Debug("inqueue2")
-					g.inqueue(vertex)
-				}
-			}
-			g.mutex.Unlock()
-			// log.Debug("read from inDegree ends")
+	return
+}
 
-			time.Sleep(100 * time.Millisecond)
+// Take returns the first ready vertex without blocking,
+// but its neighbours are not ready until Done is called.
+func (g *DiGraph) Take() (vertex interface{}, ok bool) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	g.collectReady()
+	if len(g.queue) == 0 {
+		return nil, false
+	}
+
+	vertex = g.outqueue()
+	log.Debug("DiGraph pop from queue", "vertex", vertex)
+
+	return vertex, true
+}
+
+// TakeFunc returns the first ready vertex accepted without blocking,
+// vertices not accepted stay ready, so that they could be taken later.
+func (g *DiGraph) TakeFunc(accept func(vertex interface{}) bool) (vertex interface{}, ok bool) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	g.collectReady()
+
+	ordered := slices.Clone(g.queue)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return g.before(ordered[i], ordered[j])
+	})
+
+	for _, vertex := range ordered {
+		if !accept(vertex) {
+			continue
 		}
-	}()
 
-	go func() {
-		for {
-			g.mutex.Lock()
+		i := slices.Index(g.queue, vertex)
+		g.queue = append(g.queue[:i], g.queue[i+1:]...)
+		delete(g.isInQueue, vertex)
+		log.Debug("DiGraph take from queue", "vertex", vertex)
+
+		return vertex, true
+	}
 
-			if len(g.queue) == 0 {
-				g.mutex.Unlock()
+	return nil, false
+}
 
-				time.Sleep(time.Second)
-				continue
-			}
+// Done deletes the vertex taken, so that its neighbours may be ready.
+func (g *DiGraph) Done(vertex interface{}) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	for neighbour := range g.graph[vertex] {
+		log.Debug("DiGraph walk through neighbours", "vertex", vertex, "neighbour", neighbour,
+			"neighbourInDegree", g.inDegree[neighbour])
+		g.delEdge(vertex, neighbour)
+		if g.inDegree[neighbour] == 0 {
+			g.inqueue(neighbour)
+		}
+	}
+	delete(g.graph, vertex)
+	delete(g.seq, vertex)
+}
 
-			// log.Debug("handle queue starts")
+// vertex will be deleted after consuming
+func (g *DiGraph) Pipeline() <-chan interface{} {
+	output := make(chan interface{}, g.buffer)
 
-			vertex := g.outqueue()
-			log.Debug("DiGraph pop from queue", "vertex", vertex)
-			output <- vertex
-			log.Debug("DiGraph pop from queue2", "vertex", vertex)
-
-			for neighbour := range g.graph[vertex] {
-				log.Debug("DiGraph walk through neighbours", "vertex", vertex, "neighbour", neighbour,
-					"neighbourInDegree", g.inDegree[neighbour])
-				g.delEdge(vertex, neighbour)
-				if g.inDegree[neighbour] == 0 {
-					// log.Debug("inqueue3")
-					g.inqueue(neighbour)
-				}
+	go func() {
+		for {
+			vertex, ok := g.Pop()
+			if !ok {
+				time.Sleep(100 * time.Millisecond)
+				continue
 			}
 
-			g.mutex.Unlock()
-
-			// log.Debug("handle queue ends")
+			output <- vertex
 		}
 	}()
 
 	return output
 }
 
+func (g *DiGraph) collectReady() {
+	for vertex, inDegree := range g.inDegree {
+		if inDegree == 0 {
+			g.inqueue(vertex)
+		}
+	}
+}
+
+func (g *DiGraph) addSeq(vertex interface{}) {
+	if _, ok := g.seq[vertex]; !ok {
+		g.seq[vertex] = g.nextSeq
+		g.nextSeq++
+	}
+}
+
 func (g *DiGraph) inqueue(vertex interface{}) {
 	if !g.isInQueue[vertex] {
 		log.Debug("DiGraph inqueue", "vertex", vertex)
@@ -145,11 +221,32 @@ func (g *DiGraph) inqueue(vertex interface{}) {
 	}
 }
 
+// outqueue removes the first vertex ordered by less, then by sequence.
 func (g *DiGraph) outqueue() interface{} {
-	vertex := g.queue[0]
-	g.queue = g.queue[1:]
-	g.isInQueue[vertex] = false
+	first := 0
+	for i := 1; i < len(g.queue); i++ {
+		if g.before(g.queue[i], g.queue[first]) {
+			first = i
+		}
+	}
+
+	vertex := g.queue[first]
+	g.queue = append(g.queue[:first], g.queue[first+1:]...)
+	delete(g.isInQueue, vertex)
 	log.Debug("DiGraph outqueue", "vertex", vertex)
 
 	return vertex
 }
+
+func (g *DiGraph) before(a, b interface{}) bool {
+	if g.less != nil {
+		if g.less(a, b) {
+			return true
+		}
+		if g.less(b, a) {
+			return false
+		}
+	}
+
+	return g.seq[a] < g.seq[b]
+}
@@ -72,6 +72,31 @@ func TestDiGraph(t *testing.T) {
 	}
 }
 
+func TestDiGraph_TakeFunc(t *testing.T) {
+	dag := NewDirectedGraph(10)
+	dag.AddVertex(1)
+	dag.AddVertex(2)
+	dag.AddEdge(2, 3)
+
+	odd := func(vertex interface{}) bool { return vertex.(int)%2 == 1 }
+
+	v, ok := dag.TakeFunc(odd)
+	assert.True(t, ok)
+	assert.Equal(t, 1, v)
+
+	_, ok = dag.TakeFunc(odd)
+	assert.False(t, ok, "3 is not ready")
+
+	v, ok = dag.Take()
+	assert.True(t, ok)
+	assert.Equal(t, 2, v, "vertex not accepted stays ready")
+	dag.Done(v)
+
+	v, ok = dag.TakeFunc(odd)
+	assert.True(t, ok)
+	assert.Equal(t, 3, v)
+}
+
 func isValidPipeline(dependencies [][]int, output []int) bool {
 	graph := make(map[int]map[int]bool)
 
@@ -0,0 +1,45 @@
+package semaphore
+
+import "sync"
+
+// Semaphore limits how many goroutines run at the same time, and the limit could be changed at runtime.
+type Semaphore struct {
+	limit  int
+	active int
+	cond   *sync.Cond
+}
+
+func NewSemaphore(limit int) *Semaphore {
+	return &Semaphore{
+		limit: limit,
+		cond:  sync.NewCond(&sync.Mutex{}),
+	}
+}
+
+// SetLimit changes the limit, no limit if it's not positive.
+func (s *Semaphore) SetLimit(limit int) {
+	s.cond.L.Lock()
+	defer s.cond.L.Unlock()
+
+	s.limit = limit
+	s.cond.Broadcast()
+}
+
+// Acquire blocks until the number of active goroutines is under the limit.
+func (s *Semaphore) Acquire() {
+	s.cond.L.Lock()
+	defer s.cond.L.Unlock()
+
+	for s.limit > 0 && s.active >= s.limit {
+		s.cond.Wait()
+	}
+	s.active++
+}
+
+func (s *Semaphore) Release() {
+	s.cond.L.Lock()
+	defer s.cond.L.Unlock()
+
+	s.active--
+	s.cond.Signal()
+}
@@ -0,0 +1,121 @@
+package ethclient
+
+import (
+	"context"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ivanzzeth/ethclient/common/consts"
+	"github.com/ivanzzeth/ethclient/message"
+)
+
+// holdForFunds marks the msg waiting for funds of its sender, or any account of its pool,
+// and pushes it back to the pipeline once the balance changed.
+// It reports whether the msg is held, the msg is acked to the sequencer if it's dropped while being held.
+func (c *Client) holdForFunds(ctx context.Context, msg message.Request, event message.LowBalance) bool {
+	log.Warn("hold msg for insufficient funds", "msgId", msg.Id().Hex(), "account", event.Account.Hex(),
+		"balance", event.Balance, "inFlight", event.InFlight, "required", event.Required)
+
+	// msgs of pools are held again once no accounts could cover them after being pushed back
+	err := c.msgStore.TransitMsgStatus(msg.Id(), []message.MessageStatus{message.MessageStatusScheduled,
+		message.MessageStatusQueued, message.MessageStatusWaitingForFunds}, message.MessageStatusWaitingForFunds)
+	if err != nil {
+		// e.g. cancelled meanwhile
+		log.Error("update status of msg waiting for funds failed", "msgId", msg.Id().Hex(), "err", err)
+		return false
+	}
+
+	c.lowBalanceLock.RLock()
+	for _, observer := range c.lowBalanceObservers {
+		go observer.OnLowBalance(event)
+	}
+	c.lowBalanceLock.RUnlock()
+
+	balances := map[common.Address]*big.Int{event.Account: event.Balance}
+	if msg.Pool != "" {
+		for _, account := range c.senderPool(msg.Pool) {
+			if _, ok := balances[account]; ok {
+				continue
+			}
+
+			balance, err := c.BalanceAt(ctx, account, nil)
+			if err != nil {
+				log.Warn("get balance failed", "account", account.Hex(), "err", err)
+				continue
+			}
+			balances[account] = balance
+		}
+	}
+
+	go func() {
+		for {
+			time.Sleep(consts.FundsCheckInterval)
+
+			if c.reqClosed.Load() {
+				log.Warn("ethclient closed, then drop the request", "msg", msg.Id().Hex())
+				return
+			}
+
+			stored, err := c.msgStore.GetMsg(msg.Id())
+			if err == nil && stored.Status != message.MessageStatusWaitingForFunds {
+				// e.g. cancelled
+				c.msgSequencer.AckMsg(msg.Id())
+				return
+			}
+
+			if msg.ExpirationTime != 0 && msg.ExpirationTime < time.Now().UnixNano() {
+				c.expireHeldMsg(msg)
+				c.msgSequencer.AckMsg(msg.Id())
+				return
+			}
+
+			// in-flight msgs on-chain change the balance as well
+			if c.balancesChanged(ctx, balances) {
+				c.scheduleChannel <- msg
+				return
+			}
+		}
+	}()
+
+	return true
+}
+
+// balancesChanged reports whether balance of any account differs from the one given.
+func (c *Client) balancesChanged(ctx context.Context, balances map[common.Address]*big.Int) bool {
+	for account, former := range balances {
+		balance, err := c.BalanceAt(ctx, account, nil)
+		if err != nil {
+			log.Warn("get balance failed", "account", account.Hex(), "err", err)
+			continue
+		}
+
+		if balance.Cmp(former) != 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (c *Client) expireHeldMsg(msg message.Request) {
+	locker := c.msgLock(msg.Id())
+	locker.Lock()
+	defer locker.Unlock()
+
+	stored, err := c.msgStore.GetMsg(msg.Id())
+	if err != nil || stored.Status != message.MessageStatusWaitingForFunds {
+		return
+	}
+
+	err = c.msgStore.TransitMsgStatus(msg.Id(), []message.MessageStatus{message.MessageStatusWaitingForFunds}, message.MessageStatusExpired)
+	if err != nil {
+		log.Error("update status of expired msg failed", "msgId", msg.Id().Hex(), "err", err)
+		return
+	}
+
+	resp := message.Response{Id: msg.Id(), Err: message.ErrInsufficientFunds}
+	c.msgStore.UpdateResponse(msg.Id(), resp)
+	c.respChannel <- resp
+}
@@ -0,0 +1,80 @@
+package gas
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/ethdb"
+)
+
+var _ Storage = &DBStorage{}
+
+// DBStorage keeps samples in an embedded key-value database (e.g. pebble or leveldb).
+// Locks are only held in the process, so the database must not be shared by multiple processes.
+type DBStorage struct {
+	chainId *big.Int
+	db      ethdb.KeyValueStore
+	lock    sync.Mutex
+}
+
+func NewDBStorage(chainId *big.Int, db ethdb.KeyValueStore) *DBStorage {
+	return &DBStorage{
+		chainId: chainId,
+		db:      db,
+	}
+}
+
+func (s *DBStorage) AddGasUsed(key Key, gasUsed uint64, window int) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	samples, err := s.getGasUsed(key)
+	if err != nil {
+		return err
+	}
+
+	samples = append(samples, gasUsed)
+	if len(samples) > window {
+		samples = samples[len(samples)-window:]
+	}
+
+	value, err := json.Marshal(samples)
+	if err != nil {
+		return err
+	}
+
+	return s.db.Put(s.key(key), value)
+}
+
+func (s *DBStorage) GetGasUsed(key Key) ([]uint64, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.getGasUsed(key)
+}
+
+func (s *DBStorage) getGasUsed(key Key) ([]uint64, error) {
+	samples := []uint64{}
+	has, err := s.db.Has(s.key(key))
+	if err != nil || !has {
+		return samples, err
+	}
+
+	value, err := s.db.Get(s.key(key))
+	if err != nil {
+		return nil, err
+	}
+
+	err = json.Unmarshal(value, &samples)
+	if err != nil {
+		return nil, err
+	}
+
+	return samples, nil
+}
+
+func (s *DBStorage) key(key Key) []byte {
+	return []byte(fmt.Sprintf("gas-used-chain-%s-%s", s.chainId.String(), key))
+}
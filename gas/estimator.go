@@ -0,0 +1,156 @@
+package gas
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ivanzzeth/ethclient/message"
+)
+
+const (
+	DefaultPercentile = 95
+	DefaultMargin     = 10 // percent of the percentile
+	DefaultMinSamples = 5
+	DefaultWindow     = 100
+)
+
+var _ message.GasEstimator = (*LimitEstimator)(nil)
+var _ message.MessageObserver = (*LimitEstimator)(nil)
+
+// LimitEstimator learns the gas used by calls from receipts of msgs on-chain,
+// and estimates gas limits as a high percentile of the gas used plus a margin.
+// Calls not seen enough are estimated by the backend, e.g. eth_estimateGas, with the same margin,
+// and the gas learned is used if the estimation failed.
+type LimitEstimator struct {
+	backend    ethereum.GasEstimator
+	storage    Storage
+	percentile int
+	margin     int
+	minSamples int
+	window     int
+
+	outOfGas sync.Map // key -> struct{}, estimated by the backend until the next success
+}
+
+func NewLimitEstimator(backend ethereum.GasEstimator, storage Storage) *LimitEstimator {
+	return &LimitEstimator{
+		backend:    backend,
+		storage:    storage,
+		percentile: DefaultPercentile,
+		margin:     DefaultMargin,
+		minSamples: DefaultMinSamples,
+		window:     DefaultWindow,
+	}
+}
+
+// SetPercentile sets the percentile (1-100) of gas used as the gas limit.
+func (e *LimitEstimator) SetPercentile(percentile int) {
+	e.percentile = min(max(percentile, 1), 100)
+}
+
+// SetMargin sets the percent of gas added on top of the percentile or the estimation of backend.
+func (e *LimitEstimator) SetMargin(margin int) {
+	e.margin = max(margin, 0)
+}
+
+// SetMinSamples sets how many receipts of a call are required before the gas learned is used.
+func (e *LimitEstimator) SetMinSamples(minSamples int) {
+	e.minSamples = max(minSamples, 1)
+}
+
+// SetWindow sets how many recent receipts of a call are kept.
+func (e *LimitEstimator) SetWindow(window int) {
+	e.window = max(window, 1)
+}
+
+func (e *LimitEstimator) EstimateGas(ctx context.Context, msg ethereum.CallMsg) (uint64, error) {
+	key, ok := keyOf(msg.To, msg.Data)
+	if !ok {
+		// contract creations are never learned
+		return e.estimate(ctx, msg)
+	}
+
+	samples, err := e.storage.GetGasUsed(key)
+	if err != nil {
+		log.Warn("get gas used failed", "key", key, "err", err)
+	}
+
+	_, outOfGas := e.outOfGas.Load(key)
+	if len(samples) >= e.minSamples && !outOfGas {
+		return e.withMargin(e.percentileOf(samples)), nil
+	}
+
+	gas, err := e.estimate(ctx, msg)
+	if err != nil && len(samples) > 0 && !outOfGas {
+		log.Warn("estimate gas failed, use the gas learned", "key", key, "samples", len(samples), "err", err)
+		return e.withMargin(e.percentileOf(samples)), nil
+	}
+
+	return gas, err
+}
+
+func (e *LimitEstimator) estimate(ctx context.Context, msg ethereum.CallMsg) (uint64, error) {
+	gas, err := e.backend.EstimateGas(ctx, msg)
+	if err != nil {
+		return 0, err
+	}
+
+	return e.withMargin(gas), nil
+}
+
+// OnMsgUpdated learns the gas used once the receipt of msg was recorded.
+func (e *LimitEstimator) OnMsgUpdated(oldStatus, newStatus message.MessageStatus, msg message.Message) {
+	if newStatus != message.MessageStatusOnChain || msg.Receipt == nil || msg.Receipt.TxReceipt == nil ||
+		msg.Resp == nil || msg.Resp.Tx == nil || msg.Resp.Tx.Hash() != msg.Receipt.TxReceipt.TxHash {
+		return
+	}
+
+	key, ok := keyOf(msg.Req.To, msg.Req.Data)
+	if !ok {
+		return
+	}
+
+	receipt := msg.Receipt.TxReceipt
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		if receipt.GasUsed >= msg.Resp.Tx.Gas() {
+			log.Warn("msg ran out of gas, estimate gas of the call by backend", "msgId", msg.Id().Hex(), "key", key)
+			e.outOfGas.Store(key, struct{}{})
+		}
+		// gas used by reverted calls tells nothing
+		return
+	}
+
+	err := e.storage.AddGasUsed(key, receipt.GasUsed, e.window)
+	if err != nil {
+		log.Error("add gas used failed", "msgId", msg.Id().Hex(), "key", key, "err", err)
+		return
+	}
+	e.outOfGas.Delete(key)
+}
+
+func (e *LimitEstimator) percentileOf(samples []uint64) uint64 {
+	sorted := slices.Clone(samples)
+	slices.Sort(sorted)
+
+	i := (len(sorted)*e.percentile+99)/100 - 1
+	return sorted[max(i, 0)]
+}
+
+func (e *LimitEstimator) withMargin(gas uint64) uint64 {
+	return gas + gas*uint64(e.margin)/100
+}
+
+func keyOf(to *common.Address, data []byte) (key Key, ok bool) {
+	if to == nil {
+		return Key{}, false
+	}
+
+	key.To = *to
+	copy(key.Selector[:], data)
+	return key, true
+}
@@ -0,0 +1,130 @@
+package gas
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb/pebble"
+	"github.com/ivanzzeth/ethclient/message"
+	"github.com/stretchr/testify/assert"
+)
+
+type testBackend struct {
+	gas   uint64
+	err   error
+	calls int
+}
+
+func (b *testBackend) EstimateGas(ctx context.Context, msg ethereum.CallMsg) (uint64, error) {
+	b.calls++
+	return b.gas, b.err
+}
+
+func onChainMsg(to common.Address, data []byte, gasLimit, gasUsed uint64, status uint64) message.Message {
+	req := message.AssignMessageId(&message.Request{To: &to, Data: data})
+	tx := types.NewTransaction(0, to, big.NewInt(0), gasLimit, big.NewInt(1), data)
+
+	return message.Message{
+		Req:     req,
+		Resp:    &message.Response{Id: req.Id(), Tx: tx},
+		Receipt: &message.Receipt{Id: req.Id(), TxReceipt: &types.Receipt{TxHash: tx.Hash(), Status: status, GasUsed: gasUsed}},
+		Status:  message.MessageStatusOnChain,
+	}
+}
+
+func Test_LimitEstimator(t *testing.T) {
+	ctx := context.Background()
+	to := common.HexToAddress("0x1")
+	data := []byte{1, 2, 3, 4, 5}
+	call := ethereum.CallMsg{To: &to, Data: data}
+
+	backend := &testBackend{gas: 100000}
+	e := NewLimitEstimator(backend, NewMemoryStorage())
+	e.SetMinSamples(3)
+
+	// unseen calls are estimated by backend
+	gas, err := e.EstimateGas(ctx, call)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(110000), gas)
+	assert.Equal(t, 1, backend.calls)
+
+	for _, gasUsed := range []uint64{50000, 60000} {
+		e.OnMsgUpdated(message.MessageStatusOnChain, message.MessageStatusOnChain, onChainMsg(to, data, 200000, gasUsed, types.ReceiptStatusSuccessful))
+	}
+	// reverted msgs are ignored
+	e.OnMsgUpdated(message.MessageStatusOnChain, message.MessageStatusOnChain, onChainMsg(to, data, 200000, 150000, types.ReceiptStatusFailed))
+
+	// not seen enough, but used if estimation failed
+	backend.err = errors.New("execution reverted")
+	gas, err = e.EstimateGas(ctx, call)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(66000), gas)
+
+	// other methods are not affected
+	_, err = e.EstimateGas(ctx, ethereum.CallMsg{To: &to, Data: []byte{1, 2, 3, 5}})
+	assert.NotNil(t, err)
+
+	backend.err = nil
+	e.OnMsgUpdated(message.MessageStatusOnChain, message.MessageStatusOnChain, onChainMsg(to, data, 200000, 40000, types.ReceiptStatusSuccessful))
+
+	calls := backend.calls
+	gas, err = e.EstimateGas(ctx, call)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(66000), gas, "95th percentile of gas used plus margin")
+	assert.Equal(t, calls, backend.calls, "backend not called if learned")
+
+	e.SetPercentile(50)
+	gas, _ = e.EstimateGas(ctx, call)
+	assert.Equal(t, uint64(55000), gas)
+	e.SetPercentile(DefaultPercentile)
+
+	// ran out of gas, the gas learned is stale
+	e.OnMsgUpdated(message.MessageStatusOnChain, message.MessageStatusOnChain, onChainMsg(to, data, 66000, 66000, types.ReceiptStatusFailed))
+	gas, _ = e.EstimateGas(ctx, call)
+	assert.Equal(t, uint64(110000), gas)
+
+	e.OnMsgUpdated(message.MessageStatusOnChain, message.MessageStatusOnChain, onChainMsg(to, data, 110000, 90000, types.ReceiptStatusSuccessful))
+	gas, _ = e.EstimateGas(ctx, call)
+	assert.Equal(t, uint64(99000), gas, "learned again after succeeded")
+}
+
+func Test_DBStorage_Restart(t *testing.T) {
+	dir := t.TempDir()
+	chainId := big.NewInt(1337)
+	key := Key{To: common.HexToAddress("0x1"), Selector: [4]byte{1, 2, 3, 4}}
+
+	db, err := pebble.New(dir, 16, 16, "", false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	storage := NewDBStorage(chainId, db)
+	for i := uint64(1); i <= 5; i++ {
+		err = storage.AddGasUsed(key, i, 3)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err = db.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	db, err = pebble.New(dir, 16, 16, "", false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	samples, err := NewDBStorage(chainId, db).GetGasUsed(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []uint64{3, 4, 5}, samples, "only the window kept")
+}
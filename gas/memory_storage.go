@@ -0,0 +1,36 @@
+package gas
+
+import "sync"
+
+var _ Storage = &MemoryStorage{}
+
+type MemoryStorage struct {
+	lock    sync.Mutex
+	samples map[Key][]uint64
+}
+
+func NewMemoryStorage() *MemoryStorage {
+	return &MemoryStorage{
+		samples: make(map[Key][]uint64),
+	}
+}
+
+func (s *MemoryStorage) AddGasUsed(key Key, gasUsed uint64, window int) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	samples := append(s.samples[key], gasUsed)
+	if len(samples) > window {
+		samples = append([]uint64{}, samples[len(samples)-window:]...)
+	}
+	s.samples[key] = samples
+
+	return nil
+}
+
+func (s *MemoryStorage) GetGasUsed(key Key) ([]uint64, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return append([]uint64{}, s.samples[key]...), nil
+}
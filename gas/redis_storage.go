@@ -0,0 +1,76 @@
+package gas
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strconv"
+
+	"github.com/go-redsync/redsync/v4/redis"
+)
+
+var _ Storage = &RedisStorage{}
+
+var (
+	// KEYS: samples; ARGV: gasUsed, window
+	addGasUsedScript = redis.NewScript(1, `
+redis.call("RPUSH", KEYS[1], ARGV[1])
+return redis.call("LTRIM", KEYS[1], -tonumber(ARGV[2]), -1)`)
+
+	// KEYS: samples
+	getGasUsedScript = redis.NewScript(1, `return redis.call("LRANGE", KEYS[1], 0, -1)`)
+)
+
+// RedisStorage shares samples between processes.
+type RedisStorage struct {
+	chainId   *big.Int
+	redisPool redis.Pool
+}
+
+func NewRedisStorage(chainId *big.Int, pool redis.Pool) *RedisStorage {
+	return &RedisStorage{
+		chainId:   chainId,
+		redisPool: pool,
+	}
+}
+
+func (s *RedisStorage) AddGasUsed(key Key, gasUsed uint64, window int) error {
+	conn, err := s.redisPool.Get(context.Background())
+	if err != nil {
+		return err
+	}
+
+	_, err = conn.Eval(addGasUsedScript, s.key(key), strconv.FormatUint(gasUsed, 10), strconv.Itoa(window))
+	return err
+}
+
+func (s *RedisStorage) GetGasUsed(key Key) ([]uint64, error) {
+	conn, err := s.redisPool.Get(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	reply, err := conn.Eval(getGasUsedScript, s.key(key))
+	if err != nil {
+		return nil, err
+	}
+
+	values, _ := reply.([]interface{})
+	samples := make([]uint64, 0, len(values))
+	for _, v := range values {
+		value, _ := v.(string)
+		gasUsed, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+
+		samples = append(samples, gasUsed)
+	}
+
+	return samples, nil
+}
+
+// key is a list of samples.
+func (s *RedisStorage) key(key Key) string {
+	return fmt.Sprintf("gas-used-chain-%s-%s", s.chainId.String(), key)
+}
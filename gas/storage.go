@@ -0,0 +1,26 @@
+package gas
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Key identifies calls expected to use similar gas, i.e. the same method of the same contract.
+type Key struct {
+	To       common.Address
+	Selector [4]byte // zero for plain transfers
+}
+
+func (k Key) String() string {
+	return fmt.Sprintf("%s-%x", strings.ToLower(k.To.Hex()), k.Selector)
+}
+
+// Storage keeps the gas used recently by calls of each key.
+type Storage interface {
+	// AddGasUsed appends the gas used, only the last window samples are kept.
+	AddGasUsed(key Key, gasUsed uint64, window int) error
+	// GetGasUsed returns the samples kept in the order added.
+	GetGasUsed(key Key) ([]uint64, error)
+}
@@ -0,0 +1,333 @@
+package gastank
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ivanzzeth/ethclient/message"
+)
+
+const (
+	// LabelKey labels top-up msgs, so that they are found after restart.
+	LabelKey   = "gastank"
+	LabelTopUp = "topup"
+
+	DefaultCheckInterval = time.Minute
+)
+
+// Backend submits top-ups to the pipeline of msgs, e.g. *ethclient.Client.
+type Backend interface {
+	BalanceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (*big.Int, error)
+	SubmitMsg(req *message.Request) (message.Message, error)
+	ListMsgs(filter message.MsgFilter) (msgs []message.Message, nextCursor string, err error)
+}
+
+// Config of top-ups, all amounts are in wei.
+type Config struct {
+	Treasury          common.Address // must be registered to the client for signing
+	Threshold         *big.Int       // accounts are topped up once their balances fall below, required
+	Target            *big.Int       // balance of accounts after topped up, required and not below Threshold
+	DailyCap          *big.Int       // max value topped up per UTC day, no cap if nil
+	TreasuryThreshold *big.Int       // depletion is reported once the treasury falls below, optional
+}
+
+type DepletionReason uint8
+
+const (
+	// DepletionTreasury means the treasury falls below its threshold or could not cover a top-up.
+	DepletionTreasury DepletionReason = iota + 1
+	// DepletionDailyCap means top-ups were limited by the daily cap.
+	DepletionDailyCap
+)
+
+// Depletion is reported once for every reason until recovered, e.g. the treasury was refilled or a new day began.
+type Depletion struct {
+	Reason          DepletionReason
+	Treasury        common.Address
+	TreasuryBalance *big.Int
+	SpentToday      *big.Int
+	Account         common.Address // the account not topped up, zero if none
+}
+
+// DepletionObserver is notified of the treasury depleted.
+type DepletionObserver interface {
+	OnDepletion(depletion Depletion)
+}
+
+// DepletionObserverFunc is an adapter to use ordinary functions as DepletionObserver.
+type DepletionObserverFunc func(depletion Depletion)
+
+func (f DepletionObserverFunc) OnDepletion(depletion Depletion) {
+	f(depletion)
+}
+
+var _ message.LowBalanceObserver = (*GasTank)(nil)
+
+// GasTank watches balances of accounts, and tops them up from the treasury once they fall below the threshold.
+// An account is topped up again only after its last top-up was on-chain or failed.
+// Top-ups are submitted with idempotency keys of the account, the day and how many top-ups it had that day,
+// so that the same top-up is not sent twice, e.g. by tanks of different processes.
+type GasTank struct {
+	backend  Backend
+	config   Config
+	interval time.Duration
+
+	lock      sync.Mutex // one check at a time
+	accounts  map[common.Address]struct{}
+	observers []DepletionObserver
+	reported  map[DepletionReason]int64 // reason -> day reported, until recovered
+
+	wake   chan struct{}
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func NewGasTank(backend Backend, config Config) (*GasTank, error) {
+	if config.Threshold == nil || config.Target == nil {
+		return nil, errors.New("gas tank: Threshold and Target are required")
+	}
+	if config.Target.Cmp(config.Threshold) < 0 {
+		return nil, fmt.Errorf("gas tank: Target %v is below Threshold %v", config.Target, config.Threshold)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	t := &GasTank{
+		backend:  backend,
+		config:   config,
+		interval: DefaultCheckInterval,
+		accounts: make(map[common.Address]struct{}),
+		reported: make(map[DepletionReason]int64),
+		wake:     make(chan struct{}, 1),
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+
+	go t.run()
+
+	return t, nil
+}
+
+// SetCheckInterval sets how often balances of accounts are checked.
+func (t *GasTank) SetCheckInterval(interval time.Duration) {
+	t.interval = interval
+}
+
+// Watch adds accounts topped up by the tank.
+func (t *GasTank) Watch(accounts ...common.Address) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	for _, account := range accounts {
+		t.accounts[account] = struct{}{}
+	}
+	t.Check()
+}
+
+func (t *GasTank) Unwatch(accounts ...common.Address) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	for _, account := range accounts {
+		delete(t.accounts, account)
+	}
+}
+
+func (t *GasTank) AddDepletionObserver(observer DepletionObserver) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.observers = append(t.observers, observer)
+}
+
+// Check checks balances of accounts now instead of waiting for the next interval.
+func (t *GasTank) Check() {
+	select {
+	case t.wake <- struct{}{}:
+	default:
+	}
+}
+
+// OnLowBalance checks balances once a msg was held for insufficient funds.
+func (t *GasTank) OnLowBalance(event message.LowBalance) {
+	t.Check()
+}
+
+func (t *GasTank) Close() {
+	t.cancel()
+}
+
+func (t *GasTank) run() {
+	for {
+		select {
+		case <-t.ctx.Done():
+			return
+		case <-t.wake:
+		case <-time.After(t.interval):
+		}
+
+		err := t.check()
+		if err != nil {
+			log.Warn("check balances of gas tank failed", "err", err)
+		}
+	}
+}
+
+func (t *GasTank) check() error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	now := time.Now().UTC()
+	dayStart := now.Truncate(24 * time.Hour)
+	today := dayStart.Unix()
+
+	treasuryBalance, err := t.backend.BalanceAt(t.ctx, t.config.Treasury, nil)
+	if err != nil {
+		return err
+	}
+
+	topUps, err := t.listTopUps(dayStart.UnixNano())
+	if err != nil {
+		return err
+	}
+
+	spentToday := new(big.Int)
+	// top-ups pending are not deducted from the treasury yet
+	available := new(big.Int).Set(treasuryBalance)
+	pending := make(map[common.Address]bool)
+	sequence := make(map[common.Address]int) // top-ups of accounts today, including failed ones
+	for _, msg := range topUps {
+		if msg.Req.To != nil && msg.CreatedAt >= dayStart.UnixNano() {
+			sequence[*msg.Req.To]++
+		}
+		if msg.Failed() || msg.Req.To == nil || msg.Req.Value == nil {
+			continue
+		}
+		if msg.CreatedAt >= dayStart.UnixNano() {
+			spentToday.Add(spentToday, msg.Req.Value)
+		}
+		if msg.Status != message.MessageStatusOnChain && msg.Status != message.MessageStatusFinalized {
+			pending[*msg.Req.To] = true
+			available.Sub(available, msg.Req.Value)
+		}
+	}
+
+	depletion := Depletion{Treasury: t.config.Treasury, TreasuryBalance: treasuryBalance, SpentToday: spentToday}
+
+	if t.config.TreasuryThreshold != nil && treasuryBalance.Cmp(t.config.TreasuryThreshold) < 0 {
+		t.report(DepletionTreasury, today, depletion)
+	} else {
+		delete(t.reported, DepletionTreasury)
+	}
+
+	for account := range t.accounts {
+		if pending[account] {
+			continue
+		}
+
+		balance, err := t.backend.BalanceAt(t.ctx, account, nil)
+		if err != nil {
+			log.Warn("get balance of account failed", "account", account.Hex(), "err", err)
+			continue
+		}
+
+		if balance.Cmp(t.config.Threshold) >= 0 {
+			continue
+		}
+
+		amount := new(big.Int).Sub(t.config.Target, balance)
+		depletion.Account = account
+
+		if t.config.DailyCap != nil {
+			remaining := new(big.Int).Sub(t.config.DailyCap, spentToday)
+			if amount.Cmp(remaining) > 0 {
+				t.report(DepletionDailyCap, today, depletion)
+				amount = remaining
+			}
+		}
+
+		if amount.Sign() <= 0 {
+			continue
+		}
+
+		if amount.Cmp(available) > 0 {
+			t.report(DepletionTreasury, today, depletion)
+			continue
+		}
+
+		req := &message.Request{
+			From:           t.config.Treasury,
+			To:             &account,
+			Value:          amount,
+			Labels:         map[string]string{LabelKey: LabelTopUp},
+			IdempotencyKey: fmt.Sprintf("%s:%s:%s:%d", LabelKey, account.Hex(), dayStart.Format(time.DateOnly), sequence[account]),
+		}
+		msg, err := t.backend.SubmitMsg(req)
+		if err != nil {
+			log.Warn("submit top-up failed", "account", account.Hex(), "err", err)
+			continue
+		}
+
+		// the top-up may be submitted by others before, it's deducted anyway
+		log.Info("top up account from gas tank", "msgId", msg.Id().Hex(), "account", account.Hex(),
+			"balance", balance, "amount", amount, "status", msg.Status)
+
+		spentToday.Add(spentToday, amount)
+		available.Sub(available, amount)
+	}
+
+	return nil
+}
+
+// listTopUps returns top-ups created after the time, or not done yet.
+func (t *GasTank) listTopUps(createdAfter int64) ([]message.Message, error) {
+	filter := message.MsgFilter{
+		From:   []common.Address{t.config.Treasury},
+		Labels: map[string]string{LabelKey: LabelTopUp},
+	}
+
+	list := []message.Message{}
+	for {
+		msgs, next, err := t.backend.ListMsgs(filter)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, msg := range msgs {
+			done := msg.Failed() || msg.Status == message.MessageStatusOnChain || msg.Status == message.MessageStatusFinalized
+			if msg.CreatedAt >= createdAfter || !done {
+				list = append(list, msg)
+			}
+		}
+
+		if next == "" {
+			return list, nil
+		}
+		filter.Cursor = next
+	}
+}
+
+// report notifies observers once for the reason every day until recovered.
+func (t *GasTank) report(reason DepletionReason, today int64, depletion Depletion) {
+	if day, ok := t.reported[reason]; ok && day == today {
+		return
+	}
+	t.reported[reason] = today
+
+	depletion.Reason = reason
+	// amounts keep changing while checking
+	depletion.TreasuryBalance = new(big.Int).Set(depletion.TreasuryBalance)
+	depletion.SpentToday = new(big.Int).Set(depletion.SpentToday)
+	log.Warn("gas tank depleted", "reason", reason, "treasury", depletion.Treasury.Hex(),
+		"treasuryBalance", depletion.TreasuryBalance, "spentToday", depletion.SpentToday)
+
+	for _, observer := range t.observers {
+		go observer.OnDepletion(depletion)
+	}
+}
@@ -0,0 +1,215 @@
+package gastank
+
+import (
+	"context"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ivanzzeth/ethclient/message"
+	"github.com/stretchr/testify/assert"
+)
+
+type testBackend struct {
+	lock     sync.Mutex
+	balances map[common.Address]*big.Int
+	msgs     []message.Message
+}
+
+func (b *testBackend) BalanceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (*big.Int, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if balance, ok := b.balances[account]; ok {
+		return new(big.Int).Set(balance), nil
+	}
+	return new(big.Int), nil
+}
+
+func (b *testBackend) SubmitMsg(req *message.Request) (message.Message, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	req.SetIdByIdempotencyKey()
+	for _, msg := range b.msgs {
+		if msg.Id() == req.Id() {
+			return msg, nil
+		}
+	}
+
+	msg := message.Message{Req: req.Copy(), Status: message.MessageStatusSubmitted, CreatedAt: time.Now().UnixNano()}
+	b.msgs = append(b.msgs, msg)
+	return msg, nil
+}
+
+func (b *testBackend) ListMsgs(filter message.MsgFilter) (msgs []message.Message, nextCursor string, err error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	for _, msg := range b.msgs {
+		if filter.Match(msg) {
+			msgs = append(msgs, msg)
+		}
+	}
+	return msgs, "", nil
+}
+
+// mine lands all top-ups on-chain.
+func (b *testBackend) mine() {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	for i, msg := range b.msgs {
+		if msg.Status == message.MessageStatusOnChain {
+			continue
+		}
+		b.msgs[i].Status = message.MessageStatusOnChain
+		b.balances[msg.Req.From] = new(big.Int).Sub(b.balances[msg.Req.From], msg.Req.Value)
+		b.balances[*msg.Req.To] = new(big.Int).Add(b.balances[*msg.Req.To], msg.Req.Value)
+	}
+}
+
+func Test_GasTank(t *testing.T) {
+	treasury := common.HexToAddress("0x1")
+	account1 := common.HexToAddress("0x2")
+	account2 := common.HexToAddress("0x3")
+
+	backend := &testBackend{balances: map[common.Address]*big.Int{
+		treasury: big.NewInt(1000),
+		account1: big.NewInt(10),
+		account2: big.NewInt(200),
+	}}
+
+	tank, err := NewGasTank(backend, Config{
+		Treasury:          treasury,
+		Threshold:         big.NewInt(100),
+		Target:            big.NewInt(300),
+		DailyCap:          big.NewInt(500),
+		TreasuryThreshold: big.NewInt(600),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tank.Close()
+
+	depletions := make(chan Depletion, 10)
+	tank.AddDepletionObserver(DepletionObserverFunc(func(d Depletion) {
+		depletions <- d
+	}))
+	tank.accounts[account1] = struct{}{}
+	tank.accounts[account2] = struct{}{}
+
+	err = tank.check()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if assert.Len(t, backend.msgs, 1) {
+		req := backend.msgs[0].Req
+		assert.Equal(t, treasury, req.From)
+		assert.Equal(t, account1, *req.To)
+		assert.Equal(t, big.NewInt(290), req.Value)
+		assert.Equal(t, LabelTopUp, req.Labels[LabelKey])
+	}
+
+	// not topped up twice while pending
+	backend.balances[account2] = big.NewInt(50)
+	backend.balances[account1] = big.NewInt(5)
+	err = tank.check()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if assert.Len(t, backend.msgs, 2) {
+		assert.Equal(t, account2, *backend.msgs[1].Req.To)
+		assert.Equal(t, big.NewInt(210), backend.msgs[1].Req.Value, "limited by daily cap")
+	}
+
+	select {
+	case d := <-depletions:
+		assert.Equal(t, DepletionDailyCap, d.Reason)
+		assert.Equal(t, account2, d.Account)
+		assert.Equal(t, big.NewInt(290), d.SpentToday)
+	case <-time.After(time.Second):
+		t.Fatal("daily cap not reported")
+	}
+
+	backend.mine()
+	backend.balances[account1] = big.NewInt(0)
+	err = tank.check()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, backend.msgs, 2, "daily cap reached")
+
+	select {
+	case d := <-depletions:
+		assert.Equal(t, DepletionTreasury, d.Reason)
+		assert.Equal(t, big.NewInt(500), d.TreasuryBalance)
+	case <-time.After(time.Second):
+		t.Fatal("treasury depletion not reported")
+	}
+
+	select {
+	case d := <-depletions:
+		t.Fatal("reported twice", d)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func Test_GasTank_Config(t *testing.T) {
+	backend := &testBackend{balances: map[common.Address]*big.Int{}}
+
+	for _, config := range []Config{
+		{Target: big.NewInt(300)},
+		{Threshold: big.NewInt(100)},
+		{Threshold: big.NewInt(300), Target: big.NewInt(100)},
+	} {
+		_, err := NewGasTank(backend, config)
+		assert.NotNil(t, err)
+	}
+}
+
+func Test_GasTank_IdempotentTopUps(t *testing.T) {
+	treasury := common.HexToAddress("0x1")
+	account := common.HexToAddress("0x2")
+
+	backend := &testBackend{balances: map[common.Address]*big.Int{
+		treasury: big.NewInt(1000),
+		account:  big.NewInt(10),
+	}}
+
+	config := Config{Treasury: treasury, Threshold: big.NewInt(100), Target: big.NewInt(300)}
+	tank, err := NewGasTank(backend, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tank.Close()
+	tank.accounts[account] = struct{}{}
+
+	err = tank.check()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !assert.Len(t, backend.msgs, 1) {
+		return
+	}
+	first := backend.msgs[0]
+
+	// another tank submits the same top-up before seeing the first one
+	_, err = backend.SubmitMsg(&message.Request{From: treasury, IdempotencyKey: first.Req.IdempotencyKey})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, backend.msgs, 1)
+
+	// the account is topped up again once the former top-up failed
+	backend.msgs[0].Status = message.MessageStatusExpired
+	err = tank.check()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if assert.Len(t, backend.msgs, 2) {
+		assert.NotEqual(t, first.Id(), backend.msgs[1].Id())
+	}
+}
@@ -0,0 +1,54 @@
+package ethclient
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ivanzzeth/ethclient/message"
+)
+
+// checkGuard reports whether the msg could be sent, otherwise the msg was handled by the policy of its guard,
+// and whether it will be pushed back to the sequencer.
+func (c *Client) checkGuard(ctx context.Context, msg message.Request) (met bool, requeued bool) {
+	met, err := msg.Guard.Check(ctx, c.Client, msg.From)
+	if err == nil && met {
+		return true, false
+	}
+
+	log.Info("guard of msg not met", "msgId", msg.Id().Hex(), "err", err)
+
+	expired := msg.ExpirationTime != 0 && msg.ExpirationTime < time.Now().UnixNano()
+	if msg.Guard.Policy == message.GuardRetry && !expired {
+		go func() {
+			time.Sleep(msg.Guard.RetryAfter())
+			if !c.reqClosed.Load() {
+				c.scheduleChannel <- msg
+			} else {
+				log.Warn("ethclient closed, then drop the request", "msg", msg.Id().Hex())
+			}
+		}()
+		return false, true
+	}
+
+	status := message.MessageStatusExpired
+	if msg.Guard.Policy == message.GuardSkip {
+		status = message.MessageStatusSkipped
+	}
+
+	resp := message.Response{Id: msg.Id(), Err: message.ErrGuardNotMet}
+	if err != nil {
+		resp.Err = fmt.Errorf("%w: %v", message.ErrGuardNotMet, err)
+	}
+
+	err = c.msgStore.UpdateMsgStatus(msg.Id(), status)
+	if err != nil {
+		log.Error("update status of guarded msg failed", "msgId", msg.Id().Hex(), "err", err)
+	}
+
+	c.msgStore.UpdateResponse(msg.Id(), resp)
+	c.respChannel <- resp
+
+	return false, false
+}
@@ -2,15 +2,26 @@ package message
 
 import (
 	"context"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rpc"
 )
 
 type Broadcaster interface {
 	CallAndSendMsg(ctx context.Context, msg Request) (resp Response)
 	SendMsg(ctx context.Context, msg Request) (resp Response)
+	// CancelMsg replaces the inflight msg with a zero-value self-transfer,
+	// and marks it as MessageStatusCancelled once the replacement was on-chain.
+	CancelMsg(ctx context.Context, msgId common.Hash) (resp Response)
+	// ProtectMsg keeps replacing the inflight msg with higher gas price until it's on-chain.
+	ProtectMsg(ctx context.Context, msgId common.Hash)
+	// WatchMsg keeps checking the on-chain msg for reorgs until it's MessageStatusFinalized.
+	WatchMsg(ctx context.Context, msgId common.Hash)
 }
 
 // SimpleBroadcaster makes sure that every message broadcasted could be consumed(on-chain) correctly.
@@ -18,31 +29,66 @@ type SimpleBroadcaster struct {
 	msgManager         Manager
 	blockConfirmations uint64
 	timeout            time.Duration
+	cancellations      sync.Map // msgId -> cancellation tx
+	finalityDepth      atomic.Uint64
+	finalityTag        atomic.Int64
+	watched            sync.Map // msgId -> ctx
+	watchOnce          sync.Once
+	ctx                context.Context
+	cancel             context.CancelFunc
 }
 
 func NewSimpleBroadcaster(msgManager Manager) *SimpleBroadcaster {
-	return &SimpleBroadcaster{
+	ctx, cancel := context.WithCancel(context.Background())
+
+	b := &SimpleBroadcaster{
 		msgManager:         msgManager,
 		blockConfirmations: 0, // TODO:
 		timeout:            20 * time.Second,
+		ctx:                ctx,
+		cancel:             cancel,
 	}
+	b.finalityDepth.Store(DefaultFinalityDepth)
+	b.finalityTag.Store(rpc.FinalizedBlockNumber.Int64())
+
+	return b
 }
 
-func (b SimpleBroadcaster) CallAndSendMsg(ctx context.Context, msg Request) (resp Response) {
+func (b *SimpleBroadcaster) CallAndSendMsg(ctx context.Context, msg Request) (resp Response) {
 	resp = b.msgManager.CallAndSendMsg(ctx, msg)
 
 	go b.protect(ctx, msg.Id())
 	return
 }
 
-func (b SimpleBroadcaster) SendMsg(ctx context.Context, msg Request) (resp Response) {
+func (b *SimpleBroadcaster) SendMsg(ctx context.Context, msg Request) (resp Response) {
 	resp = b.msgManager.SendMsg(ctx, msg)
+	if resp.Err != nil {
+		// e.g. held for funds, it's protected once sent
+		return
+	}
 
 	go b.protect(ctx, msg.Id())
 	return
 }
 
-func (b SimpleBroadcaster) protect(ctx context.Context, msgId common.Hash) {
+func (b *SimpleBroadcaster) CancelMsg(ctx context.Context, msgId common.Hash) (resp Response) {
+	resp = b.msgManager.CancelMsg(ctx, msgId)
+	if resp.Err != nil {
+		return
+	}
+
+	b.cancellations.Store(msgId, resp.Tx)
+
+	go b.protectCancellation(ctx, msgId, resp.Tx)
+	return
+}
+
+func (b *SimpleBroadcaster) ProtectMsg(ctx context.Context, msgId common.Hash) {
+	go b.protect(ctx, msgId)
+}
+
+func (b *SimpleBroadcaster) protect(ctx context.Context, msgId common.Hash) {
 	resp, ok := b.msgManager.WaitMsgResponse(msgId, b.timeout)
 	if !ok {
 		log.Error("no need to protect error response", "msgId", msgId)
@@ -57,9 +103,85 @@ func (b SimpleBroadcaster) protect(ctx context.Context, msgId common.Hash) {
 
 	txReceipt, ok := b.msgManager.WaitTxReceipt(resp.Tx.Hash(), b.blockConfirmations, b.timeout)
 	if !ok {
+		if b.isCancelling(msgId) {
+			log.Info("stop protecting msg being cancelled", "msgId", msgId.Hex())
+			return
+		}
+
 		b.msgManager.ReplaceMsgWithHigherGasPrice(ctx, msgId)
 		b.protect(ctx, msgId)
 	} else {
-		b.msgManager.UpdateReceipt(msgId, Receipt{Id: msgId, TxReceipt: txReceipt})
+		b.msgManager.UpdateReceiptAndStatus(msgId, &Receipt{Id: msgId, TxReceipt: txReceipt}, MessageStatusOnChain)
+		b.WatchMsg(ctx, msgId)
+	}
+}
+
+func (b *SimpleBroadcaster) isCancelling(msgId common.Hash) bool {
+	if _, ok := b.cancellations.Load(msgId); ok {
+		return true
+	}
+
+	msg, err := b.msgManager.GetMsg(msgId)
+	return err == nil && msg.Status == MessageStatusCancelled
+}
+
+// protectCancellation waits until either the cancellation or the original tx was on-chain,
+// the cancellation is replaced with a higher gas price if it was stuck like protect does.
+func (b *SimpleBroadcaster) protectCancellation(ctx context.Context, msgId common.Hash, cancelTx *types.Transaction) {
+	defer b.cancellations.Delete(msgId)
+
+	// former cancellations could still be on-chain after replaced
+	cancelTxs := []*types.Transaction{cancelTx}
+	for {
+		txReceipt, ok := b.msgManager.WaitTxReceipt(cancelTx.Hash(), b.blockConfirmations, b.timeout)
+		if ok {
+			log.Info("msg cancelled", "msgId", msgId.Hex(), "txHash", cancelTx.Hash().Hex())
+			b.msgManager.UpdateReceiptAndStatus(msgId, &Receipt{Id: msgId, TxReceipt: txReceipt}, MessageStatusCancelled)
+			return
+		}
+
+		for _, tx := range cancelTxs[:len(cancelTxs)-1] {
+			txReceipt, ok = b.msgManager.WaitTxReceipt(tx.Hash(), b.blockConfirmations, time.Second)
+			if ok {
+				log.Info("msg cancelled by replaced cancellation", "msgId", msgId.Hex(), "txHash", tx.Hash().Hex())
+				b.msgManager.UpdateReceiptAndStatus(msgId, &Receipt{Id: msgId, TxReceipt: txReceipt}, MessageStatusCancelled)
+				return
+			}
+		}
+
+		msg, err := b.msgManager.GetMsg(msgId)
+		if err != nil {
+			log.Error("get msg being cancelled failed", "msgId", msgId.Hex(), "err", err)
+			return
+		}
+
+		if msg.Receipt != nil {
+			log.Warn("msg was on-chain before cancellation", "msgId", msgId.Hex())
+			return
+		}
+
+		txReceipt, ok = b.msgManager.WaitTxReceipt(msg.Resp.Tx.Hash(), b.blockConfirmations, time.Second)
+		if ok {
+			log.Warn("msg was on-chain before cancellation", "msgId", msgId.Hex())
+			b.msgManager.UpdateReceiptAndStatus(msgId, &Receipt{Id: msgId, TxReceipt: txReceipt}, MessageStatusOnChain)
+			b.WatchMsg(ctx, msgId)
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		default:
+		}
+
+		resp := b.msgManager.ReplaceCancellation(ctx, msgId, cancelTx)
+		if resp.Err != nil {
+			log.Warn("replace cancellation failed", "msgId", msgId.Hex(), "err", resp.Err)
+			continue
+		}
+
+		cancelTx = resp.Tx
+		cancelTxs = append(cancelTxs, cancelTx)
+		b.cancellations.Store(msgId, cancelTx)
 	}
 }
@@ -0,0 +1,92 @@
+package message
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// errors which are kept identical after being decoded from persistent storages
+var knownErrors = []error{
+	ErrMsgCancelled,
+	ErrMsgInterrupted,
+	ErrDependencyFailed,
+	ErrGuardNotMet,
+	ErrSeriesStopped,
+	ErrInsufficientFunds,
+	ErrUnknownSenderPool,
+}
+
+type requestAlias Request
+
+type requestJSON struct {
+	Id common.Hash
+	*requestAlias
+}
+
+func (q Request) MarshalJSON() ([]byte, error) {
+	return json.Marshal(requestJSON{
+		Id:           q.id,
+		requestAlias: (*requestAlias)(&q),
+	})
+}
+
+func (q *Request) UnmarshalJSON(input []byte) error {
+	dec := requestJSON{requestAlias: (*requestAlias)(q)}
+	err := json.Unmarshal(input, &dec)
+	if err != nil {
+		return err
+	}
+
+	q.id = dec.Id
+	return nil
+}
+
+type responseAlias Response
+
+type responseJSON struct {
+	*responseAlias
+	Err string `json:",omitempty"`
+}
+
+func (r Response) MarshalJSON() ([]byte, error) {
+	enc := responseJSON{responseAlias: (*responseAlias)(&r)}
+	if r.Err != nil {
+		enc.Err = r.Err.Error()
+	}
+
+	return json.Marshal(enc)
+}
+
+func (r *Response) UnmarshalJSON(input []byte) error {
+	dec := responseJSON{responseAlias: (*responseAlias)(r)}
+	err := json.Unmarshal(input, &dec)
+	if err != nil {
+		return err
+	}
+
+	r.Err = decodeError(dec.Err)
+	return nil
+}
+
+func decodeError(errStr string) error {
+	if errStr == "" {
+		return nil
+	}
+
+	for _, err := range knownErrors {
+		if err.Error() == errStr {
+			return err
+		}
+
+		// wrapped by fmt.Errorf("%w: ...", err)
+		if detail, ok := strings.CutPrefix(errStr, err.Error()+": "); ok {
+			return fmt.Errorf("%w: %s", err, detail)
+		}
+	}
+
+	return errors.New(errStr)
+}
@@ -0,0 +1,86 @@
+package message
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_MsgFieldsCodec(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	signer := types.LatestSignerForChainID(big.NewInt(1337))
+
+	to := common.HexToAddress("0x1")
+	root := common.HexToHash("0x2")
+	gasOnEstimationFailed := uint64(100000)
+	req := AssignMessageId(&Request{
+		From:                  crypto.PubkeyToAddress(key.PublicKey),
+		To:                    &to,
+		Value:                 big.NewInt(1000),
+		GasOnEstimationFailed: &gasOnEstimationFailed,
+		Data:                  []byte{1, 2, 3},
+		AfterMsg:              &root,
+		AfterMsgs:             []common.Hash{root},
+		AfterConditions:       map[common.Hash]Condition{root: AfterSuccess(2)},
+		IdempotencyKey:        "payout-1",
+		SimulationOn:          true,
+		SimulationBlock:       big.NewInt(-1),
+		SimulationOverrides:   map[common.Address]AccountOverride{to: {Balance: big.NewInt(1), StateDiff: map[common.Hash]common.Hash{root: root}}},
+	})
+
+	tx, err := types.SignTx(types.NewTransaction(1, to, big.NewInt(1000), 21000, big.NewInt(1e9), nil), signer, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	simulation := &Simulation{ReturnData: []byte{4}, Revert: "reverted"}
+	msg := Message{
+		Root:   &root,
+		Req:    req,
+		Resp:   &Response{Id: req.Id(), Tx: tx, Err: ErrMsgCancelled, Simulation: simulation},
+		Status: MessageStatusCancelled,
+		Receipt: &Receipt{Id: req.Id(), TxReceipt: &types.Receipt{
+			Status:      types.ReceiptStatusSuccessful,
+			TxHash:      tx.Hash(),
+			BlockNumber: big.NewInt(10),
+			Logs:        []*types.Log{},
+		}},
+		SignedTx: tx,
+	}
+
+	args, err := encodeMsgFields(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := decodeMsgFields(args)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, msg.Id(), got.Id())
+	assert.Equal(t, msg.Req, got.Req)
+	assert.Equal(t, msg.Root, got.Root)
+	assert.Nil(t, got.Parent)
+	assert.Equal(t, msg.Status, got.Status)
+	assert.Equal(t, tx.Hash(), got.Resp.Tx.Hash())
+	assert.True(t, errors.Is(got.Resp.Err, ErrMsgCancelled))
+	assert.Equal(t, msg.Resp.Simulation, got.Resp.Simulation)
+	assert.Equal(t, tx.Hash(), got.Receipt.TxReceipt.TxHash)
+	assert.Equal(t, msg.Receipt.TxReceipt.BlockNumber, got.Receipt.TxReceipt.BlockNumber)
+	assert.Equal(t, tx.Hash(), got.SignedTx.Hash())
+}
+
+func Test_DecodeWrappedError(t *testing.T) {
+	err := fmt.Errorf("%w: msg %v", ErrDependencyFailed, common.HexToHash("0x1").Hex())
+
+	got := decodeError(err.Error())
+	assert.True(t, errors.Is(got, ErrDependencyFailed))
+	assert.Equal(t, err.Error(), got.Error())
+}
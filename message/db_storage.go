@@ -0,0 +1,357 @@
+package message
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+var _ Storage = &DBStorage{}
+
+var msgFields = []string{"req", "status", "created", "root", "parent", "resp", "receipt", "signedTx"}
+
+// DBStorage keeps messages in an embedded key-value database (e.g. pebble or leveldb),
+// so that single-host deployments survive restarts without redis.
+// Every field of a message is stored under its own key, and fields updated together are written in one batch.
+type DBStorage struct {
+	chainId *big.Int
+	db      ethdb.KeyValueStore
+	lock    sync.RWMutex // writes are serialized, and reads never see a msg partially written
+}
+
+func NewDBStorage(chainId *big.Int, db ethdb.KeyValueStore) *DBStorage {
+	return &DBStorage{
+		chainId: chainId,
+		db:      db,
+	}
+}
+
+func (s *DBStorage) AddMsg(req Request) error {
+	log.Debug("DBStorage AddMsg", "req", req)
+
+	err := checkPersisted(&req)
+	if err != nil {
+		return err
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.HasMsg(req.id) {
+		return fmt.Errorf("duplicated msg not allowed")
+	}
+
+	return s.writeMsg(Message{
+		Req:       &req,
+		Status:    MessageStatusSubmitted,
+		CreatedAt: time.Now().UnixNano(),
+	})
+}
+
+func (s *DBStorage) HasMsg(msgId common.Hash) bool {
+	has, err := s.db.Has(s.fieldKey(msgId, "req"))
+	return err == nil && has
+}
+
+func (s *DBStorage) GetMsg(msgId common.Hash) (Message, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	return s.getMsg(msgId)
+}
+
+func (s *DBStorage) getMsg(msgId common.Hash) (Message, error) {
+	fields := []interface{}{}
+	for _, field := range msgFields {
+		key := s.fieldKey(msgId, field)
+		has, err := s.db.Has(key)
+		if err != nil {
+			return Message{}, err
+		}
+
+		if !has {
+			continue
+		}
+
+		value, err := s.db.Get(key)
+		if err != nil {
+			return Message{}, err
+		}
+
+		fields = append(fields, field, string(value))
+	}
+
+	return decodeMsgFields(fields)
+}
+
+func (s *DBStorage) UpdateMsg(msg Message) error {
+	err := checkPersisted(msg.Req)
+	if err != nil {
+		return err
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.writeMsg(msg)
+}
+
+func (s *DBStorage) UpdateRequest(msgId common.Hash, req Request) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if !s.HasMsg(msgId) {
+		return fmt.Errorf("not found")
+	}
+
+	req.id = msgId
+	err := checkPersisted(&req)
+	if err != nil {
+		return err
+	}
+
+	reqBytes, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+
+	return s.db.Put(s.fieldKey(msgId, "req"), reqBytes)
+}
+
+func (s *DBStorage) UpdateResponse(msgId common.Hash, resp Response) error {
+	log.Debug("DBStorage UpdateResponse", "msgId", msgId.Hex(), "resp", resp)
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	msg, err := s.getMsg(msgId)
+	if err != nil {
+		return err
+	}
+
+	if msg.Resp != nil {
+		return fmt.Errorf("same msg not allowed updating response twice: %v", msgId.Hex())
+	}
+
+	msg.Resp = &resp
+	return s.writeMsg(msg)
+}
+
+func (s *DBStorage) UpdateReceipt(msgId common.Hash, receipt Receipt) error {
+	log.Debug("DBStorage UpdateReceipt", "msgId", msgId.Hex(),
+		"txHash", receipt.TxReceipt.TxHash.Hex(), "receipt", receipt)
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	msg, err := s.getMsg(msgId)
+	if err != nil {
+		return err
+	}
+
+	msg.Receipt = &receipt
+	return s.writeMsg(msg)
+}
+
+func (s *DBStorage) UpdateReceiptAndStatus(msgId common.Hash, receipt *Receipt, status MessageStatus) error {
+	log.Debug("DBStorage UpdateReceiptAndStatus", "msgId", msgId.Hex(), "receipt", receipt, "status", status)
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	msg, err := s.getMsg(msgId)
+	if err != nil {
+		return err
+	}
+
+	msg.Receipt = receipt
+	msg.Status = status
+	return s.writeMsg(msg)
+}
+
+func (s *DBStorage) UpdateMsgStatus(msgId common.Hash, status MessageStatus) error {
+	log.Debug("DBStorage UpdateMsgStatus", "msgId", msgId.Hex(), "status", status)
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if !s.HasMsg(msgId) {
+		return fmt.Errorf("not found")
+	}
+
+	return s.db.Put(s.fieldKey(msgId, "status"), []byte(strconv.Itoa(int(status))))
+}
+
+func (s *DBStorage) TransitMsgStatus(msgId common.Hash, from []MessageStatus, to MessageStatus) error {
+	log.Debug("DBStorage TransitMsgStatus", "msgId", msgId.Hex(), "from", from, "to", to)
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	value, err := s.db.Get(s.fieldKey(msgId, "status"))
+	if err != nil {
+		return fmt.Errorf("not found")
+	}
+
+	status, err := strconv.Atoi(string(value))
+	if err != nil {
+		return err
+	}
+
+	if !slices.Contains(from, MessageStatus(status)) {
+		return fmt.Errorf("%w: %v", ErrStatusConflict, MessageStatus(status))
+	}
+
+	return s.db.Put(s.fieldKey(msgId, "status"), []byte(strconv.Itoa(int(to))))
+}
+
+func (s *DBStorage) GetNonce(msgId common.Hash) (nonce uint64, err error) {
+	msg, err := s.GetMsg(msgId)
+	if err != nil {
+		return
+	}
+
+	if msg.Resp == nil || msg.Resp.Tx == nil {
+		return 0, fmt.Errorf("no nonce assigned")
+	}
+
+	return msg.Resp.Tx.Nonce(), nil
+}
+
+func (s *DBStorage) ListMsgs(filter MsgFilter) (msgs []Message, nextCursor string, err error) {
+	start, end := filter.cursorRange()
+	it := s.db.NewIterator(s.indexPrefix(), []byte(start))
+	defer it.Release()
+
+	for it.Next() {
+		cursor := string(it.Key()[len(s.indexPrefix()):])
+		if cursor == start {
+			continue
+		}
+
+		if end != "" && cursor >= end {
+			break
+		}
+
+		msg, err := s.GetMsg(common.BytesToHash(it.Value()))
+		if err != nil {
+			return nil, "", err
+		}
+
+		if !filter.Match(msg) {
+			continue
+		}
+
+		if len(msgs) == filter.limit() {
+			nextCursor = msgCursor(msgs[len(msgs)-1])
+			break
+		}
+
+		msgs = append(msgs, msg)
+	}
+
+	return msgs, nextCursor, it.Error()
+}
+
+func (s *DBStorage) GetSeries(rootId common.Hash) (Series, error) {
+	has, err := s.db.Has(s.seriesKey(rootId))
+	if err != nil {
+		return Series{}, err
+	}
+	if !has {
+		return Series{}, ErrSeriesNotFound
+	}
+
+	value, err := s.db.Get(s.seriesKey(rootId))
+	if err != nil {
+		return Series{}, err
+	}
+
+	var series Series
+	err = json.Unmarshal(value, &series)
+	return series, err
+}
+
+func (s *DBStorage) ListSeries() (list []Series, err error) {
+	it := s.db.NewIterator(s.seriesPrefix(), nil)
+	defer it.Release()
+
+	for it.Next() {
+		var series Series
+		err = json.Unmarshal(it.Value(), &series)
+		if err != nil {
+			return nil, err
+		}
+
+		list = append(list, series)
+	}
+
+	sortSeries(list)
+	return list, it.Error()
+}
+
+func (s *DBStorage) UpdateSeries(series Series) error {
+	log.Debug("DBStorage UpdateSeries", "rootId", series.RootId.Hex(), "status", series.Status)
+
+	value, err := json.Marshal(series)
+	if err != nil {
+		return err
+	}
+
+	return s.db.Put(s.seriesKey(series.RootId), value)
+}
+
+// writeMsg replaces all fields of the message atomically.
+func (s *DBStorage) writeMsg(msg Message) error {
+	args, err := encodeMsgFields(msg)
+	if err != nil {
+		return err
+	}
+
+	batch := s.db.NewBatch()
+	for _, field := range msgFields {
+		err = batch.Delete(s.fieldKey(msg.Id(), field))
+		if err != nil {
+			return err
+		}
+	}
+
+	for i := 0; i+1 < len(args); i += 2 {
+		err = batch.Put(s.fieldKey(msg.Id(), args[i].(string)), []byte(args[i+1].(string)))
+		if err != nil {
+			return err
+		}
+	}
+
+	err = batch.Put(append(s.indexPrefix(), msgCursor(msg)...), msg.Id().Bytes())
+	if err != nil {
+		return err
+	}
+
+	return batch.Write()
+}
+
+// indexPrefix is followed by msg cursors, so msgs are iterated in order of creation.
+func (s *DBStorage) indexPrefix() []byte {
+	return []byte(fmt.Sprintf("msg-index-chain-%s-", s.chainId.String()))
+}
+
+func (s *DBStorage) seriesPrefix() []byte {
+	return []byte(fmt.Sprintf("series-chain-%s-", s.chainId.String()))
+}
+
+func (s *DBStorage) seriesKey(rootId common.Hash) []byte {
+	return append(s.seriesPrefix(), rootId.Hex()...)
+}
+
+func (s *DBStorage) fieldKey(msgId common.Hash, field string) []byte {
+	return []byte(fmt.Sprintf("msg-chain-%s-id-%s-%s", s.chainId.String(), msgId.Hex(), field))
+}
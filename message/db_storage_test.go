@@ -0,0 +1,86 @@
+package message
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb/pebble"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_DBStorage_Restart(t *testing.T) {
+	dir := t.TempDir()
+	chainId := big.NewInt(1337)
+
+	db, err := pebble.New(dir, 16, 16, "", false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	storage := NewDBStorage(chainId, db)
+
+	to := common.HexToAddress("0x1")
+	req := AssignMessageId(&Request{From: common.HexToAddress("0x2"), To: &to, Value: big.NewInt(1)})
+
+	err = storage.AddMsg(*req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.NotNil(t, storage.AddMsg(*req), "duplicated msg not allowed")
+
+	err = storage.UpdateMsgStatus(req.Id(), MessageStatusInflight)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = storage.UpdateResponse(req.Id(), Response{Id: req.Id()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.NotNil(t, storage.UpdateResponse(req.Id(), Response{Id: req.Id()}), "updating response twice not allowed")
+
+	err = storage.UpdateReceipt(req.Id(), Receipt{Id: req.Id(), TxReceipt: &types.Receipt{BlockNumber: big.NewInt(1), Logs: []*types.Log{}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = storage.UpdateSeries(Series{RootId: req.Id(), Status: SeriesStatusPaused, CreatedAt: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = db.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	db, err = pebble.New(dir, 16, 16, "", false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	storage = NewDBStorage(chainId, db)
+
+	msg, err := storage.GetMsg(req.Id())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, req, msg.Req)
+	assert.Equal(t, MessageStatusInflight, msg.Status)
+	assert.NotNil(t, msg.Resp)
+	assert.NotNil(t, msg.Receipt)
+	assert.False(t, storage.HasMsg(common.HexToHash("0x3")))
+
+	series, err := storage.ListSeries()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []Series{{RootId: req.Id(), Status: SeriesStatusPaused, CreatedAt: 1}}, series)
+
+	_, err = storage.GetSeries(common.HexToHash("0x3"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,72 @@
+package message
+
+import (
+	"errors"
+
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// ErrDependencyFailed is the error of msgs skipped because a condition of AfterMsgs could never be met.
+var ErrDependencyFailed = errors.New("dependency failed")
+
+type ConditionKind uint8
+
+const (
+	ConditionBroadcast ConditionKind = iota
+	ConditionMined
+	ConditionSuccess
+	ConditionFailure
+)
+
+// Condition is what the predecessor must reach before the dependant is queued.
+type Condition struct {
+	Kind          ConditionKind
+	Confirmations uint64 // only used by ConditionSuccess
+}
+
+var (
+	// AfterBroadcast is met once the predecessor was broadcasted.
+	AfterBroadcast = Condition{Kind: ConditionBroadcast}
+	// AfterMined is met once the predecessor was included on-chain, even if reverted.
+	AfterMined = Condition{Kind: ConditionMined}
+	// AfterFailure is met once the predecessor failed, it's useful for compensating actions.
+	AfterFailure = Condition{Kind: ConditionFailure}
+)
+
+// AfterSuccess is met once the predecessor was executed successfully with confirmations.
+func AfterSuccess(confirmations uint64) Condition {
+	return Condition{Kind: ConditionSuccess, Confirmations: confirmations}
+}
+
+// Check reports whether the condition was met by the predecessor, or it could never be met.
+func (c Condition) Check(pred Message, blockNumber uint64) (met bool, broken bool) {
+	failed := pred.Failed()
+	mined := pred.Receipt != nil && pred.Status != MessageStatusCancelled
+
+	switch c.Kind {
+	case ConditionBroadcast:
+		met = pred.Resp != nil && pred.Resp.Err == nil && pred.Resp.Tx != nil
+	case ConditionMined:
+		met = mined
+	case ConditionSuccess:
+		met = mined && !failed && blockNumber >= pred.Receipt.TxReceipt.BlockNumber.Uint64()+c.Confirmations
+	case ConditionFailure:
+		return failed, mined && !failed
+	}
+
+	return met, !met && failed
+}
+
+// Failed reports whether the msg failed to be broadcasted or executed.
+func (m *Message) Failed() bool {
+	switch m.Status {
+	case MessageStatusSkipped, MessageStatusCancelled, MessageStatusExpired, MessageStatusNonceReleased:
+		return true
+	}
+
+	if m.Resp != nil && m.Resp.Err != nil {
+		return true
+	}
+
+	return m.Receipt != nil && m.Receipt.TxReceipt.Status == types.ReceiptStatusFailed
+}
@@ -3,6 +3,8 @@ package message
 import "github.com/ethereum/go-ethereum"
 
 type ethBackend interface {
+	ethereum.ChainReader
+	ethereum.ChainStateReader
 	ethereum.ContractCaller
 	ethereum.BlockNumberReader
 	ethereum.TransactionSender
@@ -0,0 +1,97 @@
+package message
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+const DefaultListLimit = 100
+
+// MsgFilter selects messages by ListMsgs.
+// Empty fields are ignored, and all conditions must be met.
+type MsgFilter struct {
+	Status []MessageStatus
+	From   []common.Address
+	To     []common.Address
+	Root   *common.Hash // all msgs created by the recurring task
+	Parent *common.Hash
+
+	CreatedAfter  int64 // unix nano, inclusive
+	CreatedBefore int64 // unix nano, exclusive
+
+	Labels map[string]string // msg must contain all labels
+
+	Cursor string // returned by last ListMsgs, start from the beginning if empty
+	Limit  int    // DefaultListLimit if 0
+}
+
+func (f MsgFilter) Match(msg Message) bool {
+	if len(f.Status) > 0 && !slices.Contains(f.Status, msg.Status) {
+		return false
+	}
+
+	if len(f.From) > 0 && !slices.Contains(f.From, msg.Req.From) {
+		return false
+	}
+
+	if len(f.To) > 0 && (msg.Req.To == nil || !slices.Contains(f.To, *msg.Req.To)) {
+		return false
+	}
+
+	if f.Root != nil && (msg.Root == nil || *msg.Root != *f.Root) {
+		return false
+	}
+
+	if f.Parent != nil && (msg.Parent == nil || *msg.Parent != *f.Parent) {
+		return false
+	}
+
+	if f.CreatedAfter != 0 && msg.CreatedAt < f.CreatedAfter {
+		return false
+	}
+
+	if f.CreatedBefore != 0 && msg.CreatedAt >= f.CreatedBefore {
+		return false
+	}
+
+	for k, v := range f.Labels {
+		if label, ok := msg.Req.Labels[k]; !ok || label != v {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (f MsgFilter) limit() int {
+	if f.Limit <= 0 {
+		return DefaultListLimit
+	}
+
+	return f.Limit
+}
+
+// cursorRange returns cursors where msgs matched could be, start is exclusive and end is exclusive,
+// either is empty if unbounded. So that listing recent msgs doesn't scan all of the history.
+func (f MsgFilter) cursorRange() (start, end string) {
+	start = f.Cursor
+	if f.CreatedAfter != 0 {
+		// less than cursors of all msgs created at the time
+		if after := fmt.Sprintf("%020d", f.CreatedAfter); after > start {
+			start = after
+		}
+	}
+
+	if f.CreatedBefore != 0 {
+		end = fmt.Sprintf("%020d", f.CreatedBefore)
+	}
+
+	return start, end
+}
+
+// msgCursor orders messages by creation time, it's comparable as string.
+func msgCursor(msg Message) string {
+	return fmt.Sprintf("%020d-%s", msg.CreatedAt, msg.Id().Hex())
+}
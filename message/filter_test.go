@@ -0,0 +1,98 @@
+package message
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ListMsgs(t *testing.T) {
+	storages := testStorages(t)
+
+	hotWallet := common.HexToAddress("0x1")
+	coldWallet := common.HexToAddress("0x2")
+
+	for name, storage := range storages {
+		t.Logf("run storage %v", name)
+
+		failed := []common.Hash{}
+		for i := 0; i < 10; i++ {
+			from := hotWallet
+			if i%2 == 1 {
+				from = coldWallet
+			}
+
+			req := AssignMessageId(&Request{
+				From:   from,
+				To:     &coldWallet,
+				Labels: map[string]string{"team": "treasury"},
+			})
+			if i >= 5 {
+				req.Labels["team"] = "accounting"
+			}
+
+			err := storage.AddMsg(*req)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if from == hotWallet && i < 8 {
+				err = storage.UpdateMsgStatus(req.Id(), MessageStatusExpired)
+				if err != nil {
+					t.Fatal(err)
+				}
+				failed = append(failed, req.Id())
+			}
+		}
+
+		all, next, err := storage.ListMsgs(MsgFilter{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, 10, len(all))
+		assert.Equal(t, "", next)
+
+		got := []common.Hash{}
+		filter := MsgFilter{
+			Status: []MessageStatus{MessageStatusExpired},
+			From:   []common.Address{hotWallet},
+			Limit:  3,
+		}
+		for {
+			msgs, next, err := storage.ListMsgs(filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, msg := range msgs {
+				got = append(got, msg.Id())
+			}
+
+			if next == "" {
+				break
+			}
+			filter.Cursor = next
+		}
+		assert.Equal(t, failed, got)
+
+		msgs, _, err := storage.ListMsgs(MsgFilter{
+			Labels:       map[string]string{"team": "accounting"},
+			CreatedAfter: all[6].CreatedAt,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, 4, len(msgs))
+		assert.Equal(t, all[6].Id(), msgs[0].Id())
+
+		msgs, _, err = storage.ListMsgs(MsgFilter{
+			CreatedAfter:  all[2].CreatedAt,
+			CreatedBefore: all[5].CreatedAt,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, []Message{all[2], all[3], all[4]}, msgs)
+	}
+}
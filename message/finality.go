@@ -0,0 +1,45 @@
+package message
+
+import (
+	"context"
+	"errors"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// SetFinalityTag sets the block tag for finalizing on-chain msgs, rpc.FinalizedBlockNumber by default.
+// rpc.SafeBlockNumber finalizes msgs earlier with a weaker guarantee,
+// and rpc.LatestBlockNumber only uses the finality depth, the same as chains without the tags.
+func (b *SimpleBroadcaster) SetFinalityTag(tag rpc.BlockNumber) {
+	b.finalityTag.Store(tag.Int64())
+}
+
+// finalizedBlock returns the number of the latest final block,
+// by the finality tag if the chain supports it, otherwise by the finality depth.
+func (b *SimpleBroadcaster) finalizedBlock(ctx context.Context, latest uint64) (uint64, error) {
+	tag := rpc.BlockNumber(b.finalityTag.Load())
+	if tag == rpc.SafeBlockNumber || tag == rpc.FinalizedBlockNumber {
+		header, err := b.msgManager.HeaderByNumber(ctx, big.NewInt(tag.Int64()))
+		if err == nil {
+			return header.Number.Uint64(), nil
+		}
+
+		var rpcErr rpc.Error
+		if !errors.Is(err, ethereum.NotFound) && !errors.As(err, &rpcErr) {
+			return 0, err
+		}
+
+		log.Debug("finality tag not supported, then use finality depth", "tag", tag, "err", err)
+	}
+
+	depth := b.finalityDepth.Load()
+	if latest < depth {
+		// no txs in genesis
+		return 0, nil
+	}
+
+	return latest - depth, nil
+}
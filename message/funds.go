@@ -0,0 +1,192 @@
+package message
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// ErrInsufficientFunds means the sender could not cover the value and gas fee of the msg,
+// besides those of its msgs in flight.
+var ErrInsufficientFunds = errors.New("insufficient funds")
+
+// LowBalance is emitted once a msg was held for insufficient funds of its sender.
+type LowBalance struct {
+	Account  common.Address
+	MsgId    common.Hash
+	Balance  *big.Int
+	InFlight *big.Int // cost of msgs of the account broadcasted but not on-chain yet
+	Required *big.Int // value plus gas limit * gas price of the msg
+}
+
+// LowBalanceObserver is notified of msgs held for insufficient funds, e.g. for topping up the account.
+type LowBalanceObserver interface {
+	OnLowBalance(event LowBalance)
+}
+
+// LowBalanceObserverFunc is an adapter to use ordinary functions as LowBalanceObserver.
+type LowBalanceObserverFunc func(event LowBalance)
+
+func (f LowBalanceObserverFunc) OnLowBalance(event LowBalance) {
+	f(event)
+}
+
+// FundsError is returned by sending msgs which could not be covered by their senders.
+type FundsError struct {
+	LowBalance
+}
+
+func (e *FundsError) Error() string {
+	return fmt.Sprintf("%v: account %v has balance %v, in flight %v, but required %v",
+		ErrInsufficientFunds, e.Account.Hex(), e.Balance, e.InFlight, e.Required)
+}
+
+func (e *FundsError) Unwrap() error {
+	return ErrInsufficientFunds
+}
+
+// checkFunds returns *FundsError if the sender could not cover the required besides its msgs in flight.
+func (m SimpleManager) checkFunds(ctx context.Context, msgId common.Hash, from common.Address, required *big.Int) error {
+	balance, err := m.backend.BalanceAt(ctx, from, nil)
+	if err != nil {
+		return err
+	}
+
+	_, inFlight, err := m.inFlight(msgId, from)
+	if err != nil {
+		return err
+	}
+
+	if new(big.Int).Add(inFlight, required).Cmp(balance) <= 0 {
+		return nil
+	}
+
+	return &FundsError{LowBalance{
+		Account:  from,
+		MsgId:    msgId,
+		Balance:  balance,
+		InFlight: inFlight,
+		Required: required,
+	}}
+}
+
+// inFlightIndex tracks costs of msgs in flight by their senders, so that funds are checked without scanning storages.
+// Msgs are tracked once their nonces were assigned, and dropped once they failed to be sent, or lazily once they were no longer in flight.
+// Accounts are seeded from the storage on first use, e.g. msgs in flight before restart.
+type inFlightIndex struct {
+	lock   sync.Mutex
+	seeded map[common.Address]bool
+	costs  map[common.Address]map[common.Hash]*big.Int
+}
+
+func newInFlightIndex() *inFlightIndex {
+	return &inFlightIndex{
+		seeded: make(map[common.Address]bool),
+		costs:  make(map[common.Address]map[common.Hash]*big.Int),
+	}
+}
+
+// track records the cost of the msg in flight, replacing the former one, e.g. its tx was replaced.
+func (idx *inFlightIndex) track(from common.Address, msgId common.Hash, cost *big.Int) {
+	idx.lock.Lock()
+	defer idx.lock.Unlock()
+
+	if idx.costs[from] == nil {
+		idx.costs[from] = make(map[common.Hash]*big.Int)
+	}
+	idx.costs[from][msgId] = new(big.Int).Set(cost)
+}
+
+func (idx *inFlightIndex) untrack(from common.Address, msgId common.Hash) {
+	idx.lock.Lock()
+	defer idx.lock.Unlock()
+
+	delete(idx.costs[from], msgId)
+}
+
+// snapshot returns costs of msgs tracked for the account, it's seeded by seed once.
+func (idx *inFlightIndex) snapshot(from common.Address, seed func(from common.Address) (map[common.Hash]*big.Int, error)) (map[common.Hash]*big.Int, error) {
+	idx.lock.Lock()
+	defer idx.lock.Unlock()
+
+	if !idx.seeded[from] {
+		seeded, err := seed(from)
+		if err != nil {
+			return nil, err
+		}
+
+		if idx.costs[from] == nil {
+			idx.costs[from] = make(map[common.Hash]*big.Int)
+		}
+		for msgId, cost := range seeded {
+			if _, ok := idx.costs[from][msgId]; !ok {
+				idx.costs[from][msgId] = cost
+			}
+		}
+		idx.seeded[from] = true
+	}
+
+	return maps.Clone(idx.costs[from]), nil
+}
+
+// inFlight counts msgs of the account not on-chain yet and sums up the cost of their txs, except the msg itself.
+func (m SimpleManager) inFlight(msgId common.Hash, from common.Address) (count int, cost *big.Int, err error) {
+	costs, err := m.inFlights.snapshot(from, m.listInFlight)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	cost = new(big.Int)
+	for id, c := range costs {
+		if id == msgId {
+			continue
+		}
+
+		msg, err := m.GetMsg(id)
+		if err != nil {
+			return 0, nil, err
+		}
+		if msg.Status != MessageStatusNonceAssigned && msg.Status != MessageStatusInflight {
+			m.inFlights.untrack(from, id)
+			continue
+		}
+
+		count++
+		cost.Add(cost, c)
+	}
+
+	return count, cost, nil
+}
+
+// listInFlight returns costs of msgs of the account in flight by scanning the storage.
+func (m SimpleManager) listInFlight(from common.Address) (map[common.Hash]*big.Int, error) {
+	filter := MsgFilter{
+		From:   []common.Address{from},
+		Status: []MessageStatus{MessageStatusNonceAssigned, MessageStatusInflight},
+	}
+
+	costs := make(map[common.Hash]*big.Int)
+	for {
+		msgs, next, err := m.ListMsgs(filter)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, msg := range msgs {
+			costs[msg.Id()] = new(big.Int)
+			if msg.Resp != nil && msg.Resp.Tx != nil {
+				costs[msg.Id()] = msg.Resp.Tx.Cost()
+			}
+		}
+
+		if next == "" {
+			return costs, nil
+		}
+		filter.Cursor = next
+	}
+}
@@ -0,0 +1,75 @@
+package message
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_InFlight(t *testing.T) {
+	storage, err := NewMemoryStorage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := NewSimpleManager(nil, nil, nil, storage)
+
+	from := common.HexToAddress("0x1")
+	newMsg := func(status MessageStatus) common.Hash {
+		req := AssignMessageId(&Request{From: from})
+		err := storage.AddMsg(*req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = storage.UpdateMsgStatus(req.Id(), status)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return req.Id()
+	}
+
+	// in flight before the manager started, e.g. restarted
+	seeded := newMsg(MessageStatusInflight)
+	newMsg(MessageStatusOnChain)
+
+	count, cost, err := m.inFlight(common.Hash{}, from)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 1, count)
+	assert.Equal(t, int64(0), cost.Int64())
+
+	tracked := newMsg(MessageStatusNonceAssigned)
+	m.inFlights.track(from, tracked, big.NewInt(10))
+	m.inFlights.track(from, seeded, big.NewInt(5))
+
+	count, cost, err = m.inFlight(common.Hash{}, from)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 2, count)
+	assert.Equal(t, int64(15), cost.Int64())
+
+	// the msg itself is excluded
+	count, cost, err = m.inFlight(tracked, from)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 1, count)
+	assert.Equal(t, int64(5), cost.Int64())
+
+	// msgs no longer in flight are dropped
+	err = storage.UpdateMsgStatus(seeded, MessageStatusOnChain)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	count, cost, err = m.inFlight(common.Hash{}, from)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 1, count)
+	assert.Equal(t, int64(10), cost.Int64())
+	assert.Len(t, m.inFlights.costs[from], 1)
+}
@@ -0,0 +1,144 @@
+package message
+
+import (
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"golang.org/x/time/rate"
+)
+
+// Governor limits the throughput of msgs popped from sequencers.
+// Msgs over the limits wait in the sequencer, while ready msgs of others could still be popped.
+type Governor interface {
+	// Allow reports whether the msg could be popped now, the quota is consumed if so.
+	Allow(req Request) bool
+}
+
+// ThroughputLimits are limits of msgs popped, rates are msgs per second, zero means no limit.
+type ThroughputLimits struct {
+	SenderRate      float64 // per sender
+	GlobalRate      float64
+	DestinationRate float64 // per To
+	Burst           int     // how many msgs could be popped at once by every rate, 1 if not positive
+	MaxUnconfirmed  int     // max msgs popped but not on-chain yet per sender
+}
+
+var _ Governor = (*ThroughputGovernor)(nil)
+var _ MessageObserver = (*ThroughputGovernor)(nil)
+
+// ThroughputGovernor rate limits msgs per sender, per destination and globally,
+// and caps unconfirmed msgs per sender, which are counted by observing status of msgs.
+// Limits are enforced in the process, even if the sequencer is shared by processes.
+type ThroughputGovernor struct {
+	limits ThroughputLimits
+
+	lock         sync.Mutex
+	global       *rate.Limiter
+	senders      map[common.Address]*rate.Limiter
+	destinations map[common.Address]*rate.Limiter
+	unconfirmed  map[common.Address]int
+	popped       map[common.Hash]common.Address // msgId -> sender, msgs counted as unconfirmed
+}
+
+func NewThroughputGovernor(limits ThroughputLimits) *ThroughputGovernor {
+	return &ThroughputGovernor{
+		limits:       limits,
+		global:       newLimiter(limits.GlobalRate, limits.Burst),
+		senders:      make(map[common.Address]*rate.Limiter),
+		destinations: make(map[common.Address]*rate.Limiter),
+		unconfirmed:  make(map[common.Address]int),
+		popped:       make(map[common.Hash]common.Address),
+	}
+}
+
+func (g *ThroughputGovernor) Allow(req Request) bool {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	if g.limits.MaxUnconfirmed > 0 && g.unconfirmed[req.From] >= g.limits.MaxUnconfirmed {
+		return false
+	}
+
+	limiters := []*rate.Limiter{g.global, g.limiterOf(g.senders, req.From, g.limits.SenderRate)}
+	if req.To != nil {
+		limiters = append(limiters, g.limiterOf(g.destinations, *req.To, g.limits.DestinationRate))
+	}
+	limiters = slices.DeleteFunc(limiters, func(l *rate.Limiter) bool { return l == nil })
+
+	// consume quota only if all limiters allow
+	now := time.Now()
+	for _, l := range limiters {
+		if l.TokensAt(now) < 1 {
+			return false
+		}
+	}
+	for _, l := range limiters {
+		l.AllowN(now, 1)
+	}
+
+	if _, ok := g.popped[req.Id()]; !ok {
+		g.popped[req.Id()] = req.From
+		g.unconfirmed[req.From]++
+	}
+
+	return true
+}
+
+// OnMsgUpdated stops counting msgs being on-chain, removed from the pipeline or failed to be sent.
+func (g *ThroughputGovernor) OnMsgUpdated(oldStatus, newStatus MessageStatus, msg Message) {
+	switch newStatus {
+	case MessageStatusOnChain, MessageStatusFinalized, MessageStatusNonceReleased,
+		MessageStatusExpired, MessageStatusCancelled, MessageStatusSkipped, MessageStatusWaitingForFunds:
+	default:
+		if msg.Resp == nil || msg.Resp.Err == nil {
+			return
+		}
+	}
+
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	from, ok := g.popped[msg.Id()]
+	if !ok {
+		return
+	}
+
+	delete(g.popped, msg.Id())
+	g.unconfirmed[from]--
+	if g.unconfirmed[from] <= 0 {
+		delete(g.unconfirmed, from)
+	}
+}
+
+// Unconfirmed returns how many msgs of the sender were popped but not on-chain yet.
+func (g *ThroughputGovernor) Unconfirmed(from common.Address) int {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	return g.unconfirmed[from]
+}
+
+func (g *ThroughputGovernor) limiterOf(limiters map[common.Address]*rate.Limiter, addr common.Address, r float64) *rate.Limiter {
+	if r <= 0 {
+		return nil
+	}
+
+	l, ok := limiters[addr]
+	if !ok {
+		l = newLimiter(r, g.limits.Burst)
+		limiters[addr] = l
+	}
+
+	return l
+}
+
+// newLimiter returns nil if not limited.
+func newLimiter(r float64, burst int) *rate.Limiter {
+	if r <= 0 {
+		return nil
+	}
+
+	return rate.NewLimiter(rate.Limit(r), max(burst, 1))
+}
@@ -0,0 +1,99 @@
+package message
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ThroughputGovernor(t *testing.T) {
+	from1 := common.HexToAddress("0x1")
+	from2 := common.HexToAddress("0x2")
+	to1 := common.HexToAddress("0x3")
+	to2 := common.HexToAddress("0x4")
+
+	newReq := func(from, to common.Address) Request {
+		return *AssignMessageId(&Request{From: from, To: &to, Value: big.NewInt(0)})
+	}
+
+	// rates are too low to refill in the test
+	g := NewThroughputGovernor(ThroughputLimits{SenderRate: 0.001, DestinationRate: 0.001, Burst: 2, MaxUnconfirmed: 2})
+
+	req1 := newReq(from1, to1)
+	assert.True(t, g.Allow(req1))
+	req2 := newReq(from1, to2)
+	assert.True(t, g.Allow(req2))
+	assert.Equal(t, 2, g.Unconfirmed(from1))
+	assert.False(t, g.Allow(newReq(from1, to2)), "too many unconfirmed msgs of sender")
+
+	g.OnMsgUpdated(MessageStatusInflight, MessageStatusOnChain, Message{Req: &req1, Status: MessageStatusOnChain})
+	assert.Equal(t, 1, g.Unconfirmed(from1))
+	assert.False(t, g.Allow(newReq(from1, to2)), "over the rate of sender")
+
+	assert.True(t, g.Allow(newReq(from2, to1)))
+	assert.False(t, g.Allow(newReq(from2, to1)), "over the rate of destination")
+	assert.True(t, g.Allow(newReq(from2, to2)), "quota not consumed if not allowed")
+
+	// failed to be sent
+	g.OnMsgUpdated(MessageStatusQueued, MessageStatusQueued, Message{Req: &req2, Resp: &Response{Id: req2.Id(), Err: errors.New("failed")}})
+	assert.Equal(t, 0, g.Unconfirmed(from1))
+
+	global := NewThroughputGovernor(ThroughputLimits{GlobalRate: 0.001})
+	assert.True(t, global.Allow(newReq(from1, to1)))
+	assert.False(t, global.Allow(newReq(from2, to2)), "over the global rate")
+}
+
+func Test_Sequencer_GovernorSkipsResponded(t *testing.T) {
+	storage, err := NewMemoryStorage()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	from := common.HexToAddress("0x1")
+	sequencers := map[string]Sequencer{
+		"memory": NewMemorySequencer(nil, storage, 10),
+		"redis":  NewRedisSequencer(big.NewInt(1337), newTestRedisPool(t), storage),
+	}
+
+	for name, sequencer := range sequencers {
+		t.Run(name, func(t *testing.T) {
+			defer sequencer.Close()
+
+			g := NewThroughputGovernor(ThroughputLimits{MaxUnconfirmed: 2})
+			sequencer.SetGovernor(g)
+			popped := consumeMsgs(sequencer)
+
+			// cancelled while queued
+			for i := 0; i < 3; i++ {
+				req := AssignMessageId(&Request{From: from})
+				addTestMsgs(t, storage, req)
+				err := storage.UpdateResponse(req.Id(), Response{Id: req.Id(), Err: ErrMsgCancelled})
+				if err != nil {
+					t.Fatal(err)
+				}
+				err = sequencer.PushMsg(*req)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			req := AssignMessageId(&Request{From: from})
+			addTestMsgs(t, storage, req)
+			err := sequencer.PushMsg(*req)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			msg, ok := popMsgWithin(popped, 2*time.Second)
+			if !ok {
+				t.Fatal("msg was not popped")
+			}
+			assert.Equal(t, req.Id(), msg.Id())
+			assert.Equal(t, 1, g.Unconfirmed(from), "cancelled msgs must not be counted")
+		})
+	}
+}
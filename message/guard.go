@@ -0,0 +1,111 @@
+package message
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+var (
+	// ErrGuardNotMet is the error of msgs skipped or expired because the guard was not met.
+	ErrGuardNotMet = errors.New("guard not met")
+	// ErrGuardPredicateNotPersisted is returned by persistent storages for msgs whose guard has a Predicate.
+	ErrGuardPredicateNotPersisted = errors.New("guard predicate could not be persisted")
+)
+
+type GuardOp uint8
+
+const (
+	GuardEq GuardOp = iota
+	GuardNe
+	GuardGt // results are compared as uint256 by GuardGt, GuardGte, GuardLt and GuardLte
+	GuardGte
+	GuardLt
+	GuardLte
+)
+
+// GuardPolicy is what to do with the msg if the guard was not met.
+type GuardPolicy uint8
+
+const (
+	// GuardSkip marks the msg as MessageStatusSkipped, the next msg of the Interval task checks the guard again.
+	GuardSkip GuardPolicy = iota
+	// GuardRetry checks the guard again after RetryInterval until ExpirationTime.
+	GuardRetry
+	// GuardExpire marks the msg as MessageStatusExpired.
+	GuardExpire
+)
+
+// Guard is an eth_call checked right before the msg is signed,
+// so that the msg is sent only if on-chain state still needs it.
+type Guard struct {
+	To       common.Address
+	Data     []byte
+	Op       GuardOp
+	Expected []byte
+	// Predicate is used instead of Op and Expected if not nil.
+	// NOTE: it's only supported by MemoryStorage, persistent storages reject msgs with it.
+	Predicate func(result []byte) bool `json:"-"`
+
+	Policy        GuardPolicy
+	RetryInterval time.Duration // DefaultGuardRetryInterval if 0
+}
+
+const DefaultGuardRetryInterval = 10 * time.Second
+
+// Check calls the guard on the latest block, the guard was not met if the call reverted.
+func (g *Guard) Check(ctx context.Context, caller ethereum.ContractCaller, from common.Address) (bool, error) {
+	result, err := caller.CallContract(ctx, ethereum.CallMsg{From: from, To: &g.To, Data: g.Data}, nil)
+	if err != nil {
+		return false, err
+	}
+
+	if g.Predicate != nil {
+		return g.Predicate(result), nil
+	}
+
+	switch g.Op {
+	case GuardEq:
+		return bytes.Equal(result, g.Expected), nil
+	case GuardNe:
+		return !bytes.Equal(result, g.Expected), nil
+	}
+
+	cmp := new(big.Int).SetBytes(result).Cmp(new(big.Int).SetBytes(g.Expected))
+	switch g.Op {
+	case GuardGt:
+		return cmp > 0, nil
+	case GuardGte:
+		return cmp >= 0, nil
+	case GuardLt:
+		return cmp < 0, nil
+	case GuardLte:
+		return cmp <= 0, nil
+	}
+
+	return false, nil
+}
+
+// RetryAfter returns how long to wait for checking the guard again by GuardRetry.
+func (g *Guard) RetryAfter() time.Duration {
+	if g.RetryInterval == 0 {
+		return DefaultGuardRetryInterval
+	}
+
+	return g.RetryInterval
+}
+
+// checkPersisted returns ErrGuardPredicateNotPersisted if the guard of the request could not be persisted.
+func checkPersisted(req *Request) error {
+	if req != nil && req.Guard != nil && req.Guard.Predicate != nil {
+		return fmt.Errorf("%w: %v", ErrGuardPredicateNotPersisted, req.id.Hex())
+	}
+
+	return nil
+}
@@ -21,16 +21,30 @@ type ScheduleManager interface {
 
 	SendMsg(ctx context.Context, msg Request) (resp Response)
 	ReplaceMsgWithHigherGasPrice(ctx context.Context, msgId common.Hash) (resp Response)
+	// replace the inflight msg with a zero-value self-transfer at the same nonce.
+	CancelMsg(ctx context.Context, msgId common.Hash) (resp Response)
+	// replace the cancellation stuck in the mempool with a higher gas price.
+	ReplaceCancellation(ctx context.Context, msgId common.Hash, cancelTx *types.Transaction) (resp Response)
 	// replace old msg with same nonce.
 	// mark old one as MessageStatusNonceReleased
 	// ReplaceMsg(ctx context.Context, msgId common.Hash, newMsg Request) (resp Response)
 
+	// RebroadcastMsg sends the tx of the msg again if it's missing from the mempool, e.g. dropped by a reorg.
+	RebroadcastMsg(ctx context.Context, msgId common.Hash) (resp Response)
+
 	NewTransaction(ctx context.Context, msg Request) (*types.Transaction, error)
 	MessageToTransactOpts(ctx context.Context, msg Request) (*bind.TransactOpts, error)
 
+	BlockNumber(ctx context.Context) (uint64, error)
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+	TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error)
+
 	WaitTxReceipt(txHash common.Hash, confirmations uint64, timeout time.Duration) (*types.Receipt, bool)
 	WaitMsgResponse(msgId common.Hash, timeout time.Duration) (*Response, bool)
 	WaitMsgReceipt(msgId common.Hash, confirmations uint64, timeout time.Duration) (*Receipt, bool)
+	// WaitMsgFinalized waits until the msg is MessageStatusFinalized,
+	// it returns false once the msg was terminated without being on-chain.
+	WaitMsgFinalized(msgId common.Hash, timeout time.Duration) (*Receipt, bool)
 }
 
 // type StatusManager interface {
@@ -2,6 +2,8 @@ package message
 
 import (
 	"errors"
+	"math"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -18,29 +20,65 @@ var ErrPendingChannelClosed = errors.New("pending channel was closed")
 type MemorySequencer struct {
 	client       *ethclient.Client
 	closed       atomic.Bool
+	done         chan struct{}
+	stopped      chan struct{}
 	msgStorage   Storage
 	dag          *graph.DiGraph
 	queuedReq    chan Request
 	queuedCount  atomic.Int64
+	queued       sync.Map // msgId -> queuedMsg
 	pendingReq   chan Request
 	pendingCount atomic.Int64
+
+	priorityAging    time.Duration
+	deadlineOrdering bool
+	governor         Governor
+}
+
+type queuedMsg struct {
+	req   Request
+	score int64
+	level int64 // effective priority level, the lower the earlier
 }
 
 func NewMemorySequencer(client *ethclient.Client, msgStorage Storage, buffer int) *MemorySequencer {
 	s := &MemorySequencer{
-		client:     client,
-		msgStorage: msgStorage,
-		dag:        graph.NewDirectedGraph(buffer),
-		queuedReq:  make(chan Request, buffer),
-		pendingReq: make(chan Request, buffer),
+		client:        client,
+		done:          make(chan struct{}),
+		stopped:       make(chan struct{}),
+		msgStorage:    msgStorage,
+		dag:           graph.NewDirectedGraph(buffer),
+		queuedReq:     make(chan Request, buffer),
+		pendingReq:    make(chan Request),
+		priorityAging: DefaultPriorityAging,
 	}
 
+	s.dag.SetLess(s.less)
+
 	go s.run()
 
 	return s
 }
 
+// SetPriorityAging sets how long a msg waits for its priority being raised by 1.
+func (s *MemorySequencer) SetPriorityAging(aging time.Duration) {
+	s.priorityAging = aging
+}
+
+// SetDeadlineOrdering pops ready msgs of the same effective priority level in order of ExpirationTime if enabled.
+// Msgs waiting for an aging period are raised to the next level, msgs without ExpirationTime are popped last in a level.
+// It's not supported by RedisSequencer, which pops msgs by priority only.
+func (s *MemorySequencer) SetDeadlineOrdering(enabled bool) {
+	s.deadlineOrdering = enabled
+}
+
+func (s *MemorySequencer) SetGovernor(governor Governor) {
+	s.governor = governor
+}
+
 func (s *MemorySequencer) PushMsg(msg Request) error {
+	score := priorityScore(msg, time.Now(), s.priorityAging)
+	s.queued.Store(msg.Id(), queuedMsg{req: msg, score: score, level: priorityLevel(score, s.priorityAging)})
 	s.queuedReq <- msg
 	s.queuedCount.Add(1)
 
@@ -63,12 +101,18 @@ func (s *MemorySequencer) PeekMsg() (Request, error) {
 	return Request{}, nil
 }
 
+// AckMsg releases dependants of the msg.
+func (s *MemorySequencer) AckMsg(msgId common.Hash) error {
+	s.dag.Done(msgId)
+	return nil
+}
+
 func (s *MemorySequencer) QueuedMsgCount() (int, error) {
 	return int(s.queuedCount.Load()), nil
 }
 
 func (s *MemorySequencer) PendingMsgCount() (int, error) {
-	return int(s.pendingCount.Load()), nil
+	return int(s.pendingCount.Load()) + s.dag.ReadyCount(), nil
 }
 
 func (s *MemorySequencer) Close() {
@@ -81,6 +125,9 @@ func (s *MemorySequencer) Close() {
 	// Wait for all messages to be sent
 	time.Sleep(3 * time.Second)
 
+	close(s.done)
+	<-s.stopped
+
 	close(s.queuedReq)
 	close(s.pendingReq)
 }
@@ -105,24 +152,105 @@ func (s *MemorySequencer) run() {
 		}
 	}()
 
-	for reqId := range s.dag.Pipeline() {
+	defer close(s.stopped)
+
+	for {
+		reqId, ok := s.take()
+		if !ok {
+			select {
+			case <-s.done:
+				return
+			case <-time.After(10 * time.Millisecond):
+			}
+			continue
+		}
+
 		log.Debug("push req from dag", "req ID", reqId)
+		s.queued.Delete(reqId)
 		msg, err := s.msgStorage.GetMsg(reqId.(common.Hash))
 		if err != nil {
 			log.Error("AddMsg first before using sequencer", "err", err)
+			s.dag.Done(reqId)
 			continue
 		}
 
 		if msg.Resp != nil {
 			log.Debug("msg already responded", "msgId", msg.Id().Hex())
+			s.dag.Done(reqId)
+			continue
+		}
+
+		if s.closed.Load() {
+			log.Warn("ethclient closed, then drop the request", "msg", msg.Id().Hex())
 			continue
 		}
 
-		if !s.closed.Load() {
-			s.pendingCount.Add(1)
-			s.pendingReq <- *msg.Req
-		} else {
+		// the msg is picked only when the consumer is ready, so later msgs with higher priority could overtake.
+		s.pendingCount.Add(1)
+		select {
+		case s.pendingReq <- *msg.Req:
+		case <-s.done:
 			log.Warn("ethclient closed, then drop the request", "msg", msg.Id().Hex())
+			return
 		}
 	}
 }
+
+// take returns the first ready msg allowed by the governor.
+func (s *MemorySequencer) take() (interface{}, bool) {
+	governor := s.governor
+	if governor == nil {
+		return s.dag.Take()
+	}
+
+	return s.dag.TakeFunc(func(vertex interface{}) bool {
+		// msgs which could not be sent are taken anyway, then dropped by run without consuming the quota
+		msg, err := s.msgStorage.GetMsg(vertex.(common.Hash))
+		if err != nil || msg.Resp != nil {
+			return true
+		}
+
+		return governor.Allow(*msg.Req)
+	})
+}
+
+func (s *MemorySequencer) less(a, b interface{}) bool {
+	qa, _ := s.queued.Load(a)
+	qb, _ := s.queued.Load(b)
+	ma, _ := qa.(queuedMsg)
+	mb, _ := qb.(queuedMsg)
+
+	// compare levels, deadlines in a level and scores at last, so that the order is total
+	if s.deadlineOrdering {
+		if ma.level != mb.level {
+			return ma.level < mb.level
+		}
+
+		da, db := deadlineOf(ma.req), deadlineOf(mb.req)
+		if da != db {
+			return da < db
+		}
+	}
+
+	return ma.score < mb.score
+}
+
+// priorityLevel groups scores by aging periods, msgs in the same group are of the same effective priority.
+func priorityLevel(score int64, aging time.Duration) int64 {
+	period := max(aging.Milliseconds(), 1)
+	level := score / period
+	if score%period < 0 {
+		level--
+	}
+
+	return level
+}
+
+// deadlineOf returns ExpirationTime of the msg, msgs without it have the latest deadline.
+func deadlineOf(req Request) int64 {
+	if req.ExpirationTime == 0 {
+		return math.MaxInt64
+	}
+
+	return req.ExpirationTime
+}
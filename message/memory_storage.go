@@ -2,7 +2,10 @@ package message
 
 import (
 	"fmt"
+	"slices"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/log"
@@ -11,7 +14,9 @@ import (
 var _ Storage = &MemoryStorage{}
 
 type MemoryStorage struct {
-	store sync.Map
+	store  sync.Map
+	series sync.Map
+	lock   sync.Mutex // serializes read-modify-writes of msgs
 }
 
 func NewMemoryStorage() (*MemoryStorage, error) {
@@ -20,14 +25,15 @@ func NewMemoryStorage() (*MemoryStorage, error) {
 
 func (s *MemoryStorage) AddMsg(req Request) error {
 	log.Debug("MemoryStorage AddMsg", "req", req)
-	if s.HasMsg(req.id) {
+	_, loaded := s.store.LoadOrStore(req.id, Message{
+		Req:       &req,
+		Status:    MessageStatusSubmitted,
+		CreatedAt: time.Now().UnixNano(),
+	})
+	if loaded {
 		return fmt.Errorf("duplicated msg not allowed")
 	}
 
-	s.store.Store(req.id, Message{
-		Req:    &req,
-		Status: MessageStatusSubmitted,
-	})
 	return nil
 }
 
@@ -47,50 +53,93 @@ func (s *MemoryStorage) GetMsg(msgId common.Hash) (Message, error) {
 }
 
 func (s *MemoryStorage) UpdateMsg(msg Message) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
 	s.store.Store(msg.Req.id, msg)
 	return nil
 }
 
+func (s *MemoryStorage) UpdateRequest(msgId common.Hash, req Request) error {
+	return s.update(msgId, func(msg *Message) error {
+		req.id = msgId
+		msg.Req = &req
+		return nil
+	})
+}
+
 func (s *MemoryStorage) UpdateResponse(msgId common.Hash, resp Response) error {
 	log.Debug("MemoryStorage UpdateResponse", "msgId", msgId.Hex(), "resp", resp)
 
-	msg, err := s.GetMsg(msgId)
-	if err != nil {
-		return err
-	}
-
-	if msg.Resp != nil {
-		panic("same msg not allowed updating response twice" + msgId.Hex())
-	}
+	return s.update(msgId, func(msg *Message) error {
+		if msg.Resp != nil {
+			panic("same msg not allowed updating response twice" + msgId.Hex())
+		}
 
-	msg.Resp = &resp
-	return s.UpdateMsg(msg)
+		msg.Resp = &resp
+		return nil
+	})
 }
 
 func (s *MemoryStorage) UpdateReceipt(msgId common.Hash, receipt Receipt) error {
 	log.Debug("MemoryStorage UpdateReceipt", "msgId", msgId.Hex(),
 		"txHash", receipt.TxReceipt.TxHash.Hex(), "receipt", receipt)
 
-	msg, err := s.GetMsg(msgId)
-	if err != nil {
-		return err
-	}
+	return s.update(msgId, func(msg *Message) error {
+		msg.Receipt = &receipt
+		return nil
+	})
+}
+
+func (s *MemoryStorage) UpdateReceiptAndStatus(msgId common.Hash, receipt *Receipt, status MessageStatus) error {
+	log.Debug("MemoryStorage UpdateReceiptAndStatus", "msgId", msgId.Hex(), "receipt", receipt, "status", status)
 
-	msg.Receipt = &receipt
-	return s.UpdateMsg(msg)
+	return s.update(msgId, func(msg *Message) error {
+		msg.Receipt = receipt
+		msg.Status = status
+		return nil
+	})
 }
 
 func (s *MemoryStorage) UpdateMsgStatus(msgId common.Hash, status MessageStatus) error {
 	log.Debug("MemoryStorage UpdateMsgStatus", "msgId", msgId.Hex(), "status", status)
 
+	return s.update(msgId, func(msg *Message) error {
+		msg.Status = status
+		return nil
+	})
+}
+
+func (s *MemoryStorage) TransitMsgStatus(msgId common.Hash, from []MessageStatus, to MessageStatus) error {
+	log.Debug("MemoryStorage TransitMsgStatus", "msgId", msgId.Hex(), "from", from, "to", to)
+
+	return s.update(msgId, func(msg *Message) error {
+		if !slices.Contains(from, msg.Status) {
+			return fmt.Errorf("%w: %v", ErrStatusConflict, msg.Status)
+		}
+
+		msg.Status = to
+		return nil
+	})
+}
+
+// update modifies the msg by updateFn atomically.
+func (s *MemoryStorage) update(msgId common.Hash, updateFn func(msg *Message) error) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
 	msg, err := s.GetMsg(msgId)
 	if err != nil {
 		return err
 	}
 
-	msg.Status = status
+	err = updateFn(&msg)
+	if err != nil {
+		return err
+	}
 
-	return s.UpdateMsg(msg)
+	s.store.Store(msgId, msg)
+	return nil
 }
 
 func (s *MemoryStorage) GetNonce(msgId common.Hash) (nonce uint64, err error) {
@@ -105,3 +154,51 @@ func (s *MemoryStorage) GetNonce(msgId common.Hash) (nonce uint64, err error) {
 
 	return msg.Resp.Tx.Nonce(), nil
 }
+
+func (s *MemoryStorage) ListMsgs(filter MsgFilter) (msgs []Message, nextCursor string, err error) {
+	start, _ := filter.cursorRange()
+	s.store.Range(func(_, value any) bool {
+		msg := value.(Message)
+		if msgCursor(msg) > start && filter.Match(msg) {
+			msgs = append(msgs, msg)
+		}
+		return true
+	})
+
+	slices.SortFunc(msgs, func(a, b Message) int {
+		return strings.Compare(msgCursor(a), msgCursor(b))
+	})
+
+	if len(msgs) > filter.limit() {
+		msgs = msgs[:filter.limit()]
+		nextCursor = msgCursor(msgs[len(msgs)-1])
+	}
+
+	return msgs, nextCursor, nil
+}
+
+func (s *MemoryStorage) GetSeries(rootId common.Hash) (Series, error) {
+	series, ok := s.series.Load(rootId)
+	if !ok {
+		return Series{}, ErrSeriesNotFound
+	}
+
+	return series.(Series), nil
+}
+
+func (s *MemoryStorage) ListSeries() (list []Series, err error) {
+	s.series.Range(func(_, value any) bool {
+		list = append(list, value.(Series))
+		return true
+	})
+
+	sortSeries(list)
+	return list, nil
+}
+
+func (s *MemoryStorage) UpdateSeries(series Series) error {
+	log.Debug("MemoryStorage UpdateSeries", "rootId", series.RootId.Hex(), "status", series.Status)
+
+	s.series.Store(series.RootId, series)
+	return nil
+}
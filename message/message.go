@@ -1,7 +1,10 @@
 package message
 
 import (
+	"errors"
+	"maps"
 	"math/big"
+	"slices"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -10,6 +13,11 @@ import (
 	"github.com/google/uuid"
 )
 
+var (
+	ErrMsgCancelled   = errors.New("msg cancelled")
+	ErrMsgInterrupted = errors.New("msg broadcasting interrupted")
+)
+
 type Message struct {
 	Root    *common.Hash
 	Parent  *common.Hash // it's created by parent if not nil
@@ -17,6 +25,10 @@ type Message struct {
 	Resp    *Response // not nil if inflight
 	Receipt *Receipt  // not nil if on-chain
 	Status  MessageStatus
+
+	SignedTx *types.Transaction // kept right before being broadcasted, so that msgs interrupted before being responded could be reconciled
+
+	CreatedAt int64 // unix nano, when the msg was added to storage
 }
 
 func (m *Message) Id() common.Hash {
@@ -26,6 +38,7 @@ func (m *Message) Id() common.Hash {
 type Request struct {
 	id                    common.Hash
 	From                  common.Address  // the sender of the 'transaction'
+	Pool                  string          // the sender pool, From is picked from it before the msg is queued and again at nonce assignment if not empty
 	To                    *common.Address // the destination contract (nil for contract creation)
 	Value                 *big.Int        // amount of wei sent along with the call
 	Gas                   uint64          // if 0, the call executes with near-infinite gas
@@ -35,12 +48,28 @@ type Request struct {
 
 	AccessList types.AccessList // EIP-2930 access list.
 
-	SimulationOn bool // contains return data of msg call if true
+	SimulationOn        bool                               // contains return data of msg call if true, and the msg is not sent if the call failed
+	SimulationBlock     *big.Int                           // block number or tag (e.g. rpc.PendingBlockNumber) of simulation, latest if nil
+	SimulationOverrides map[common.Address]AccountOverride // state overrides of simulation, e.g. balances, code and storage slots
+
+	Labels map[string]string // user-defined metadata, used for querying msgs
+
+	IdempotencyKey string // if not empty, the msg id is derived from it and From (or Pool), so the msg is sent once no matter how many times it was submitted.
+
+	Guard *Guard // checked right before signing, the msg is sent only if it was met.
+
 	// ONLY available on function ScheduleMsg
-	AfterMsg       *common.Hash  // message id or txHash. Used for making sure the msg was executed after it.
-	StartTime      int64         // the msg was executed after the time. It's useful for one-time task.
-	ExpirationTime int64         // the msg will be not included on-chain if timeout.
-	Interval       time.Duration // the msg will be executed every interval.
+	AfterMsg        *common.Hash              // message id or txHash. Used for making sure the msg was executed after it.
+	AfterMsgs       []common.Hash             // message ids. The msg is not queued until conditions of all of them are met.
+	AfterConditions map[common.Hash]Condition // conditions of AfterMsgs, AfterBroadcast by default.
+	StartTime       int64                     // the msg was executed after the time. It's useful for one-time task.
+	ExpirationTime  int64                     // the msg will be not included on-chain if timeout.
+	Interval        time.Duration             // the msg will be executed every interval.
+	Cron            string                    // cron expression, e.g. "0 */4 * * *". The msg will be executed at times of the schedule instead of every interval.
+	CronTimeZone    string                    // IANA time zone of Cron, e.g. "Asia/Shanghai". UTC if empty.
+	MaxRuns         uint64                    // how many msgs the recurring task creates at most, including this one. No limit if 0.
+	EndTime         int64                     // the recurring task creates no more msgs starting after the time.
+	Priority        int                       // ready msgs with higher priority are broadcasted first, 0 by default.
 }
 
 type MessageStatus uint8
@@ -56,12 +85,19 @@ const (
 	// it was broadcasted but not included on-chain until timeout, so the nonce was released
 	MessageStatusNonceReleased
 	MessageStatusExpired
+	// it was removed from the pipeline, or replaced on-chain by a zero-value self-transfer with the same nonce
+	MessageStatusCancelled
+	// it was not broadcasted because a condition of AfterMsgs could never be met
+	MessageStatusSkipped
+	// it was not broadcasted because its sender could not cover it, and will be retried once the balance changed
+	MessageStatusWaitingForFunds
 )
 
 type Response struct {
 	Id         common.Hash
 	Tx         *types.Transaction
-	ReturnData []byte // not nil if using SafeScheduleMsg and no err
+	ReturnData []byte      // not nil if using SafeScheduleMsg and no err
+	Simulation *Simulation // not nil if SimulationOn
 	Err        error
 }
 
@@ -92,6 +128,11 @@ func GenerateMessageIdByAddressAndNonce(address common.Address, nonce uint64) *c
 	return &hash
 }
 
+func GenerateMessageIdByIdempotencyKey(from common.Address, key string) *common.Hash {
+	hash := crypto.Keccak256Hash(from.Bytes(), []byte(key))
+	return &hash
+}
+
 func (m *Request) Id() common.Hash {
 	return m.id
 }
@@ -106,6 +147,18 @@ func (q *Request) SetIdWithNonce(nonce int64) *Request {
 	return q
 }
 
+// SetIdByIdempotencyKey derives the msg id from IdempotencyKey and From,
+// or Pool if it's set, since From is not picked yet.
+func (q *Request) SetIdByIdempotencyKey() *Request {
+	if q.Pool != "" {
+		q.id = *GenerateMessageIdByIdempotencyKey(common.Address{}, "pool:"+q.Pool+":"+q.IdempotencyKey)
+		return q
+	}
+
+	q.id = *GenerateMessageIdByIdempotencyKey(q.From, q.IdempotencyKey)
+	return q
+}
+
 func (q *Request) SetRandomId() *Request {
 	AssignMessageId(q)
 	return q
@@ -122,6 +175,8 @@ func (q *Request) CopyWithoutId() *Request {
 	var (
 		gasOnEstimationFailed *uint64
 		value, gasPrice       *big.Int
+		simulationBlock       *big.Int
+		guard                 *Guard
 	)
 
 	if q.GasOnEstimationFailed != nil {
@@ -137,8 +192,18 @@ func (q *Request) CopyWithoutId() *Request {
 		gasPrice = big.NewInt(0).Set(q.GasPrice)
 	}
 
+	if q.Guard != nil {
+		g := *q.Guard
+		guard = &g
+	}
+
+	if q.SimulationBlock != nil {
+		simulationBlock = big.NewInt(0).Set(q.SimulationBlock)
+	}
+
 	req := Request{
 		From:                  q.From,
+		Pool:                  q.Pool,
 		To:                    q.To,
 		Value:                 value,
 		Gas:                   q.Gas,
@@ -147,11 +212,23 @@ func (q *Request) CopyWithoutId() *Request {
 		Data:                  q.Data,
 		AccessList:            q.AccessList,
 		SimulationOn:          q.SimulationOn,
-
-		AfterMsg:       q.AfterMsg,
-		StartTime:      q.StartTime,
-		ExpirationTime: q.ExpirationTime,
-		Interval:       q.Interval,
+		SimulationBlock:       simulationBlock,
+		SimulationOverrides:   maps.Clone(q.SimulationOverrides),
+		Labels:                maps.Clone(q.Labels),
+		IdempotencyKey:        q.IdempotencyKey,
+		Guard:                 guard,
+
+		AfterMsg:        q.AfterMsg,
+		AfterMsgs:       slices.Clone(q.AfterMsgs),
+		AfterConditions: maps.Clone(q.AfterConditions),
+		StartTime:       q.StartTime,
+		ExpirationTime:  q.ExpirationTime,
+		Interval:        q.Interval,
+		Cron:            q.Cron,
+		CronTimeZone:    q.CronTimeZone,
+		MaxRuns:         q.MaxRuns,
+		EndTime:         q.EndTime,
+		Priority:        q.Priority,
 	}
 
 	return &req
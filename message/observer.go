@@ -0,0 +1,168 @@
+package message
+
+import (
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// MessageObserver is notified after the status, response or receipt of a msg was updated.
+// Notifications of the same msg are delivered in order, and a slow observer never blocks sending msgs.
+type MessageObserver interface {
+	OnMsgUpdated(oldStatus, newStatus MessageStatus, msg Message)
+}
+
+// MessageObserverFunc is an adapter to use ordinary functions as MessageObserver.
+type MessageObserverFunc func(oldStatus, newStatus MessageStatus, msg Message)
+
+func (f MessageObserverFunc) OnMsgUpdated(oldStatus, newStatus MessageStatus, msg Message) {
+	f(oldStatus, newStatus, msg)
+}
+
+var _ Storage = (*ObservedStorage)(nil)
+
+// msgLockStripes is the number of locks shared by msgs, updates of the same msg are serialized by one of them.
+const msgLockStripes = 64
+
+// ObservedStorage notifies observers of updates on the underlying storage.
+type ObservedStorage struct {
+	Storage
+
+	lock      sync.RWMutex
+	observers []MessageObserver
+
+	msgLocks [msgLockStripes]sync.Mutex
+
+	queueLock sync.Mutex
+	queues    map[common.Hash][]msgUpdate // pending notifications of msgs being delivered
+}
+
+type msgUpdate struct {
+	oldStatus MessageStatus
+	msg       Message
+}
+
+func NewObservedStorage(storage Storage) *ObservedStorage {
+	return &ObservedStorage{
+		Storage: storage,
+		queues:  make(map[common.Hash][]msgUpdate),
+	}
+}
+
+func (s *ObservedStorage) AddObserver(observer MessageObserver) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.observers = append(s.observers, observer)
+}
+
+func (s *ObservedStorage) UpdateResponse(msgId common.Hash, resp Response) error {
+	return s.update(msgId, func() error {
+		return s.Storage.UpdateResponse(msgId, resp)
+	})
+}
+
+func (s *ObservedStorage) UpdateReceipt(msgId common.Hash, receipt Receipt) error {
+	return s.update(msgId, func() error {
+		return s.Storage.UpdateReceipt(msgId, receipt)
+	})
+}
+
+func (s *ObservedStorage) UpdateReceiptAndStatus(msgId common.Hash, receipt *Receipt, status MessageStatus) error {
+	return s.update(msgId, func() error {
+		return s.Storage.UpdateReceiptAndStatus(msgId, receipt, status)
+	})
+}
+
+func (s *ObservedStorage) UpdateMsgStatus(msgId common.Hash, status MessageStatus) error {
+	return s.update(msgId, func() error {
+		return s.Storage.UpdateMsgStatus(msgId, status)
+	})
+}
+
+func (s *ObservedStorage) TransitMsgStatus(msgId common.Hash, from []MessageStatus, to MessageStatus) error {
+	return s.update(msgId, func() error {
+		return s.Storage.TransitMsgStatus(msgId, from, to)
+	})
+}
+
+func (s *ObservedStorage) update(msgId common.Hash, updateFn func() error) error {
+	s.lock.RLock()
+	observed := len(s.observers) > 0
+	s.lock.RUnlock()
+	if !observed {
+		return updateFn()
+	}
+
+	// so that the old status and the order of notifications are exact
+	locker := &s.msgLocks[msgId[0]%msgLockStripes]
+	locker.Lock()
+	defer locker.Unlock()
+
+	old, err := s.Storage.GetMsg(msgId)
+	if err != nil {
+		return updateFn()
+	}
+
+	err = updateFn()
+	if err != nil {
+		return err
+	}
+
+	msg, err := s.Storage.GetMsg(msgId)
+	if err != nil {
+		log.Error("get msg for notifying observers failed", "msgId", msgId.Hex(), "err", err)
+		return nil
+	}
+
+	s.enqueue(msgUpdate{oldStatus: old.Status, msg: msg})
+	return nil
+}
+
+// enqueue never blocks, notifications are delivered by one goroutine per msg as long as any is pending.
+func (s *ObservedStorage) enqueue(update msgUpdate) {
+	msgId := update.msg.Id()
+
+	s.queueLock.Lock()
+	queue, delivering := s.queues[msgId]
+	s.queues[msgId] = append(queue, update)
+	s.queueLock.Unlock()
+
+	if !delivering {
+		go s.deliver(msgId)
+	}
+}
+
+func (s *ObservedStorage) deliver(msgId common.Hash) {
+	for {
+		s.queueLock.Lock()
+		queue := s.queues[msgId]
+		if len(queue) == 0 {
+			delete(s.queues, msgId)
+			s.queueLock.Unlock()
+			return
+		}
+		update := queue[0]
+		s.queues[msgId] = queue[1:]
+		s.queueLock.Unlock()
+
+		s.lock.RLock()
+		observers := s.observers
+		s.lock.RUnlock()
+
+		for _, observer := range observers {
+			notify(observer, update)
+		}
+	}
+}
+
+func notify(observer MessageObserver, update msgUpdate) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Error("msg observer panicked", "msgId", update.msg.Id().Hex(), "err", r)
+		}
+	}()
+
+	observer.OnMsgUpdated(update.oldStatus, update.msg.Status, update.msg)
+}
@@ -0,0 +1,71 @@
+package message
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ObservedStorage(t *testing.T) {
+	memStorage, err := NewMemoryStorage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	storage := NewObservedStorage(memStorage)
+
+	var (
+		lock    sync.Mutex
+		changes [][2]MessageStatus
+	)
+	release := make(chan struct{})
+	storage.AddObserver(MessageObserverFunc(func(oldStatus, newStatus MessageStatus, msg Message) {
+		<-release
+
+		lock.Lock()
+		defer lock.Unlock()
+		changes = append(changes, [2]MessageStatus{oldStatus, newStatus})
+	}))
+
+	to := common.HexToAddress("0x1")
+	req := AssignMessageId(&Request{From: common.HexToAddress("0x2"), To: &to})
+	err = storage.AddMsg(*req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	statuses := []MessageStatus{MessageStatusScheduled, MessageStatusQueued, MessageStatusNonceAssigned, MessageStatusInflight}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for _, status := range statuses {
+			err := storage.UpdateMsgStatus(req.Id(), status)
+			assert.Nil(t, err)
+		}
+		err := storage.UpdateResponse(req.Id(), Response{Id: req.Id()})
+		assert.Nil(t, err)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("updates blocked by slow observer")
+	}
+
+	close(release)
+	assert.Eventually(t, func() bool {
+		lock.Lock()
+		defer lock.Unlock()
+		return len(changes) == 5
+	}, time.Second, 10*time.Millisecond)
+
+	assert.Equal(t, [][2]MessageStatus{
+		{MessageStatusSubmitted, MessageStatusScheduled},
+		{MessageStatusScheduled, MessageStatusQueued},
+		{MessageStatusQueued, MessageStatusNonceAssigned},
+		{MessageStatusNonceAssigned, MessageStatusInflight},
+		{MessageStatusInflight, MessageStatusInflight},
+	}, changes)
+}
@@ -0,0 +1,273 @@
+package message
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+var ErrUnknownSenderPool = errors.New("unknown sender pool")
+
+// senderPools holds accounts of named sender pools,
+// and msgs assigned to accounts which are not in flight yet.
+type senderPools struct {
+	lock     sync.RWMutex
+	pools    map[string][]common.Address
+	assigned map[common.Address]map[common.Hash]struct{}
+}
+
+func newSenderPools() *senderPools {
+	return &senderPools{
+		pools:    make(map[string][]common.Address),
+		assigned: make(map[common.Address]map[common.Hash]struct{}),
+	}
+}
+
+// assign moves the msg to the account from the one assigned before.
+func (p *senderPools) assign(account common.Address, msgId common.Hash) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	for _, msgs := range p.assigned {
+		delete(msgs, msgId)
+	}
+
+	if p.assigned[account] == nil {
+		p.assigned[account] = make(map[common.Hash]struct{})
+	}
+	p.assigned[account][msgId] = struct{}{}
+}
+
+func (p *senderPools) unassign(account common.Address, msgId common.Hash) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	delete(p.assigned[account], msgId)
+}
+
+func (p *senderPools) assignedMsgs(account common.Address) []common.Hash {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	msgIds := make([]common.Hash, 0, len(p.assigned[account]))
+	for msgId := range p.assigned[account] {
+		msgIds = append(msgIds, msgId)
+	}
+
+	return msgIds
+}
+
+// SetSenderPool sets accounts of the pool, msgs with the Pool are sent by one of them.
+// The pool is removed if no accounts were given.
+func (m *SimpleManager) SetSenderPool(name string, accounts ...common.Address) {
+	m.pools.lock.Lock()
+	defer m.pools.lock.Unlock()
+
+	if len(accounts) == 0 {
+		delete(m.pools.pools, name)
+		return
+	}
+
+	m.pools.pools[name] = slices.Clone(accounts)
+}
+
+// SenderPool returns accounts of the pool, nil if it doesn't exist.
+func (m *SimpleManager) SenderPool(name string) []common.Address {
+	m.pools.lock.RLock()
+	defer m.pools.lock.RUnlock()
+
+	return slices.Clone(m.pools.pools[name])
+}
+
+// AssignSender picks the sender of the msg from its pool and records it in the storage.
+// The account with the fewest msgs in flight or assigned is picked among those could cover the msg,
+// if none of them could, *FundsError of the one with the most funds available is returned.
+// The msg is returned as is if it has no pool.
+func (m *SimpleManager) AssignSender(ctx context.Context, msg Request) (Request, error) {
+	if msg.Pool == "" {
+		return msg, nil
+	}
+
+	loads, err := m.senderLoads(ctx, msg)
+	if err != nil {
+		return msg, err
+	}
+
+	picked := leastLoaded(loads)
+	if picked == nil {
+		var shortest *senderLoad
+		for _, load := range loads {
+			if shortest == nil || load.available().Cmp(shortest.available()) > 0 {
+				shortest = load
+			}
+		}
+
+		msg.From = shortest.Account
+		return msg, &FundsError{shortest.LowBalance}
+	}
+
+	msg.From = picked.Account
+	return msg, m.assignSender(msg, picked.count)
+}
+
+// ReassignSender picks the sender of the msg from its pool again right before its nonce is assigned,
+// since funds and load of accounts change while the msg is waiting for being broadcasted.
+// The sender is kept unless it could no longer cover the msg or another account has fewer msgs in flight or assigned,
+// and it's kept as well if none of them could cover the msg, which is then held for funds of the sender.
+// It reports whether the sender was changed.
+func (m *SimpleManager) ReassignSender(ctx context.Context, msg Request) (Request, bool, error) {
+	if msg.Pool == "" {
+		return msg, false, nil
+	}
+
+	loads, err := m.senderLoads(ctx, msg)
+	if err != nil {
+		return msg, false, err
+	}
+
+	picked := leastLoaded(loads)
+	if picked == nil || picked.Account == msg.From {
+		return msg, false, nil
+	}
+
+	for _, load := range loads {
+		if load.Account == msg.From && load.covered() && load.count <= picked.count {
+			return msg, false, nil
+		}
+	}
+
+	log.Info("reassign sender from pool", "msgId", msg.Id().Hex(), "pool", msg.Pool, "from", msg.From.Hex(), "to", picked.Account.Hex())
+
+	msg.From = picked.Account
+	return msg, true, m.assignSender(msg, picked.count)
+}
+
+// senderLoad is how many msgs and how much funds of the account are taken by others than the msg.
+type senderLoad struct {
+	LowBalance
+	count int
+}
+
+func (l *senderLoad) available() *big.Int {
+	return new(big.Int).Sub(l.Balance, l.InFlight)
+}
+
+func (l *senderLoad) covered() bool {
+	return l.available().Cmp(l.Required) >= 0
+}
+
+// leastLoaded returns the account with the fewest msgs among those could cover the msg, nil if none of them could.
+func leastLoaded(loads []*senderLoad) *senderLoad {
+	var picked *senderLoad
+	for _, load := range loads {
+		if load.covered() && (picked == nil || load.count < picked.count) {
+			picked = load
+		}
+	}
+
+	return picked
+}
+
+// senderLoads returns loads of accounts in the pool of the msg.
+func (m *SimpleManager) senderLoads(ctx context.Context, msg Request) ([]*senderLoad, error) {
+	accounts := m.SenderPool(msg.Pool)
+	if len(accounts) == 0 {
+		return nil, fmt.Errorf("%w: %v", ErrUnknownSenderPool, msg.Pool)
+	}
+
+	// the gas limit is unknown before estimation, then it's covered by the funds check at nonce assignment
+	required := new(big.Int)
+	if msg.Value != nil {
+		required.Add(required, msg.Value)
+	}
+	if msg.Gas > 0 && msg.GasPrice != nil {
+		required.Add(required, new(big.Int).Mul(new(big.Int).SetUint64(msg.Gas), msg.GasPrice))
+	}
+
+	loads := make([]*senderLoad, 0, len(accounts))
+	for _, account := range accounts {
+		count, inFlight, err := m.inFlight(msg.Id(), account)
+		if err != nil {
+			return nil, err
+		}
+
+		// msgs assigned before are not in flight until broadcasted
+		assignedCount, assignedCost, err := m.assignedPending(msg.Id(), account)
+		if err != nil {
+			return nil, err
+		}
+		count += assignedCount
+		inFlight.Add(inFlight, assignedCost)
+
+		balance, err := m.backend.BalanceAt(ctx, account, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		loads = append(loads, &senderLoad{
+			LowBalance: LowBalance{
+				Account:  account,
+				MsgId:    msg.Id(),
+				Balance:  balance,
+				InFlight: inFlight,
+				Required: required,
+			},
+			count: count,
+		})
+	}
+
+	return loads, nil
+}
+
+// assignSender records From of the msg as its sender picked from the pool.
+func (m *SimpleManager) assignSender(msg Request, inFlight int) error {
+	err := m.UpdateRequest(msg.Id(), msg)
+	if err != nil {
+		return err
+	}
+	m.pools.assign(msg.From, msg.Id())
+
+	log.Info("assign sender from pool", "msgId", msg.Id().Hex(), "pool", msg.Pool, "from", msg.From.Hex(), "inFlight", inFlight)
+
+	return nil
+}
+
+// assignedPending counts msgs assigned to the account which are waiting for being broadcasted,
+// and sums up their value and gas fee if known, except the msg itself.
+func (m *SimpleManager) assignedPending(msgId common.Hash, account common.Address) (count int, cost *big.Int, err error) {
+	cost = new(big.Int)
+	for _, id := range m.pools.assignedMsgs(account) {
+		if id == msgId {
+			continue
+		}
+
+		msg, err := m.GetMsg(id)
+		if err != nil {
+			return 0, nil, err
+		}
+
+		switch msg.Status {
+		case MessageStatusSubmitted, MessageStatusScheduled, MessageStatusQueued:
+		default:
+			// in flight, done or held for funds, which is assigned again once pushed back
+			m.pools.unassign(account, id)
+			continue
+		}
+
+		count++
+		if msg.Req.Value != nil {
+			cost.Add(cost, msg.Req.Value)
+		}
+		if msg.Req.Gas > 0 && msg.Req.GasPrice != nil {
+			cost.Add(cost, new(big.Int).Mul(new(big.Int).SetUint64(msg.Req.Gas), msg.Req.GasPrice))
+		}
+	}
+
+	return count, cost, nil
+}
@@ -0,0 +1,497 @@
+package message
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/go-redsync/redsync/v4/redis"
+)
+
+var _ Sequencer = &RedisSequencer{}
+
+var (
+	// KEYS: queued, ready, blocked, waiters of pred, scores, leases; ARGV: id, pred, predStored, score
+	seqPushScript = redis.NewScript(6, `
+if redis.call("SISMEMBER", KEYS[1], ARGV[1]) == 1 then
+	if redis.call("ZREM", KEYS[6], ARGV[1]) == 0 then
+		return 0
+	end
+	redis.call("HSET", KEYS[5], ARGV[1], ARGV[4])
+	redis.call("ZADD", KEYS[2], ARGV[4], ARGV[1])
+	return 1
+end
+redis.call("SADD", KEYS[1], ARGV[1])
+redis.call("HSET", KEYS[5], ARGV[1], ARGV[4])
+if ARGV[2] ~= "" and (ARGV[3] == "0" or redis.call("SISMEMBER", KEYS[1], ARGV[2]) == 1) then
+	redis.call("HSET", KEYS[3], ARGV[1], ARGV[2])
+	redis.call("SADD", KEYS[4], ARGV[1])
+	return 1
+end
+redis.call("ZADD", KEYS[2], ARGV[4], ARGV[1])
+return 1
+`)
+
+	// KEYS: ready, leases; ARGV: lease deadline
+	seqPopScript = redis.NewScript(2, `
+local popped = redis.call("ZPOPMIN", KEYS[1])
+if #popped == 0 then
+	return false
+end
+redis.call("ZADD", KEYS[2], ARGV[1], popped[1])
+return popped[1]
+`)
+
+	// KEYS: ready, leases; ARGV: id, lease deadline
+	seqTakeScript = redis.NewScript(2, `
+if redis.call("ZREM", KEYS[1], ARGV[1]) == 0 then
+	return 0
+end
+return redis.call("ZADD", KEYS[2], ARGV[2], ARGV[1])
+`)
+
+	// KEYS: ready; ARGV: start, count
+	seqRangeScript = redis.NewScript(1, `return redis.call("ZRANGE", KEYS[1], ARGV[1], tonumber(ARGV[1]) + tonumber(ARGV[2]) - 1)`)
+
+	// KEYS: ready
+	seqPeekScript = redis.NewScript(1, `return redis.call("ZRANGE", KEYS[1], 0, 0)[1]`)
+
+	// KEYS: queued, leases, waiters of id, ready, blocked, scores; ARGV: id
+	seqAckScript = redis.NewScript(6, `
+redis.call("SREM", KEYS[1], ARGV[1])
+redis.call("ZREM", KEYS[2], ARGV[1])
+redis.call("HDEL", KEYS[6], ARGV[1])
+local waiters = redis.call("SMEMBERS", KEYS[3])
+for _, w in ipairs(waiters) do
+	if redis.call("HDEL", KEYS[5], w) == 1 then
+		redis.call("ZADD", KEYS[4], redis.call("HGET", KEYS[6], w) or 0, w)
+	end
+end
+redis.call("DEL", KEYS[3])
+return #waiters
+`)
+
+	// KEYS: queued, blocked, waiters of pred, ready, scores; ARGV: id, pred
+	seqReleaseScript = redis.NewScript(5, `
+if redis.call("SISMEMBER", KEYS[1], ARGV[2]) == 1 then
+	return 0
+end
+if redis.call("HDEL", KEYS[2], ARGV[1]) == 0 then
+	return 0
+end
+redis.call("SREM", KEYS[3], ARGV[1])
+redis.call("ZADD", KEYS[4], redis.call("HGET", KEYS[5], ARGV[1]) or 0, ARGV[1])
+return 1
+`)
+
+	// KEYS: leases, ready, scores; ARGV: now
+	seqReapScript = redis.NewScript(3, `
+local ids = redis.call("ZRANGEBYSCORE", KEYS[1], "-inf", ARGV[1])
+for _, id in ipairs(ids) do
+	redis.call("ZREM", KEYS[1], id)
+	redis.call("ZADD", KEYS[2], redis.call("HGET", KEYS[3], id) or 0, id)
+end
+return #ids
+`)
+
+	// KEYS: leases; ARGV: lease deadline, id
+	seqRenewScript = redis.NewScript(1, `return redis.call("ZADD", KEYS[1], "XX", ARGV[1], ARGV[2])`)
+
+	// KEYS: blocked
+	seqBlockedScript = redis.NewScript(1, `return redis.call("HGETALL", KEYS[1])`)
+
+	// KEYS: blocked
+	seqQueuedCountScript = redis.NewScript(1, `return redis.call("HLEN", KEYS[1])`)
+
+	// KEYS: ready
+	seqPendingCountScript = redis.NewScript(1, `return redis.call("ZCARD", KEYS[1])`)
+)
+
+const DefaultLeaseTimeout = time.Minute
+
+// governedPopWindow is how many ready msgs are loaded at once while being checked by the governor.
+const governedPopWindow = 100
+
+// RedisSequencer shares one logical queue between processes.
+// The dependency graph lives in redis, so AfterMsg ordering holds across processes.
+//
+// Popped msgs are leased to the consumer until AckMsg is called,
+// and returned to the queue if the consumer died before that.
+// A leased msg pushed again returns to the queue without releasing its dependants.
+// Dependants are released only after their predecessor was acked.
+//
+// Ready msgs are popped by priority, and ordering by deadline is not supported.
+type RedisSequencer struct {
+	chainId       *big.Int
+	redisPool     redis.Pool
+	msgStorage    Storage
+	leaseTimeout  time.Duration
+	priorityAging time.Duration
+	governor      Governor
+	leased        sync.Map
+	closed        atomic.Bool
+	ctx           context.Context
+	cancel        context.CancelFunc
+}
+
+func NewRedisSequencer(chainId *big.Int, pool redis.Pool, msgStorage Storage) *RedisSequencer {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	s := &RedisSequencer{
+		chainId:       chainId,
+		redisPool:     pool,
+		msgStorage:    msgStorage,
+		leaseTimeout:  DefaultLeaseTimeout,
+		priorityAging: DefaultPriorityAging,
+		ctx:           ctx,
+		cancel:        cancel,
+	}
+
+	go s.run()
+
+	return s
+}
+
+// SetLeaseTimeout sets how long a popped msg is kept from other consumers without being acked.
+// Leases are renewed while the consumer is alive.
+func (s *RedisSequencer) SetLeaseTimeout(timeout time.Duration) {
+	s.leaseTimeout = timeout
+}
+
+// SetPriorityAging sets how long a msg waits for its priority being raised by 1.
+// All processes sharing the queue should use the same aging.
+func (s *RedisSequencer) SetPriorityAging(aging time.Duration) {
+	s.priorityAging = aging
+}
+
+// SetGovernor limits msgs popped by this process,
+// ready msgs not allowed are kept in the queue, which could be popped by other processes.
+func (s *RedisSequencer) SetGovernor(governor Governor) {
+	s.governor = governor
+}
+
+func (s *RedisSequencer) PushMsg(msg Request) error {
+	pred := ""
+	predStored := "0"
+	waitersKey := s.waitersKey(common.Hash{})
+	if msg.AfterMsg != nil && *msg.AfterMsg != msg.Id() {
+		pred = msg.AfterMsg.Hex()
+		waitersKey = s.waitersKey(*msg.AfterMsg)
+		if s.msgStorage.HasMsg(*msg.AfterMsg) {
+			predStored = "1"
+		}
+	}
+
+	conn, err := s.redisPool.Get(context.Background())
+	if err != nil {
+		return err
+	}
+
+	_, err = conn.Eval(seqPushScript, s.queuedKey(), s.readyKey(), s.blockedKey(), waitersKey, s.scoresKey(),
+		s.leasesKey(), msg.Id().Hex(), pred, predStored, priorityScore(msg, time.Now(), s.priorityAging))
+	if err != nil {
+		return err
+	}
+
+	s.leased.Delete(msg.Id())
+
+	return nil
+}
+
+func (s *RedisSequencer) PopMsg() (Request, error) {
+	for !s.closed.Load() {
+		id, err := s.pop()
+		if err != nil {
+			log.Warn("pop msg from redis failed", "err", err)
+			time.Sleep(time.Second)
+			continue
+		}
+
+		if id == nil {
+			time.Sleep(100 * time.Millisecond)
+			continue
+		}
+
+		msg, err := s.msgStorage.GetMsg(*id)
+		if err != nil {
+			log.Error("AddMsg first before using sequencer", "err", err)
+			s.AckMsg(*id)
+			continue
+		}
+
+		if msg.Resp != nil {
+			log.Debug("msg already responded", "msgId", msg.Id().Hex())
+			s.AckMsg(*id)
+			continue
+		}
+
+		log.Debug("Pop req from redis", "req ID", id.Hex())
+		return *msg.Req, nil
+	}
+
+	return Request{}, ErrPendingChannelClosed
+}
+
+func (s *RedisSequencer) PeekMsg() (Request, error) {
+	conn, err := s.redisPool.Get(context.Background())
+	if err != nil {
+		return Request{}, err
+	}
+
+	reply, err := conn.Eval(seqPeekScript, s.readyKey())
+	if err != nil {
+		return Request{}, err
+	}
+
+	id, ok := reply.(string)
+	if !ok {
+		return Request{}, nil
+	}
+
+	msg, err := s.msgStorage.GetMsg(common.HexToHash(id))
+	if err != nil {
+		return Request{}, err
+	}
+
+	return *msg.Req, nil
+}
+
+func (s *RedisSequencer) AckMsg(msgId common.Hash) error {
+	s.leased.Delete(msgId)
+
+	conn, err := s.redisPool.Get(context.Background())
+	if err != nil {
+		return err
+	}
+
+	_, err = conn.Eval(seqAckScript, s.queuedKey(), s.leasesKey(), s.waitersKey(msgId), s.readyKey(), s.blockedKey(),
+		s.scoresKey(), msgId.Hex())
+	return err
+}
+
+func (s *RedisSequencer) QueuedMsgCount() (int, error) {
+	return s.count(seqQueuedCountScript, s.blockedKey())
+}
+
+func (s *RedisSequencer) PendingMsgCount() (int, error) {
+	return s.count(seqPendingCountScript, s.readyKey())
+}
+
+// Close stops consuming, msgs leased but not acked will be consumed by other processes after lease timeout.
+func (s *RedisSequencer) Close() {
+	if s.closed.Load() {
+		return
+	}
+
+	s.closed.Store(true)
+	s.cancel()
+}
+
+func (s *RedisSequencer) pop() (*common.Hash, error) {
+	conn, err := s.redisPool.Get(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	if s.governor != nil {
+		return s.popGoverned(conn)
+	}
+
+	reply, err := conn.Eval(seqPopScript, s.readyKey(), s.leasesKey(), s.leaseDeadline())
+	if err != nil {
+		return nil, err
+	}
+
+	id, ok := reply.(string)
+	if !ok {
+		return nil, nil
+	}
+
+	msgId := common.HexToHash(id)
+	s.leased.Store(msgId, struct{}{})
+
+	return &msgId, nil
+}
+
+// popGoverned pops the first ready msg allowed by the governor,
+// all ready msgs are checked, so that msgs held by the governor never hide others.
+func (s *RedisSequencer) popGoverned(conn redis.Conn) (*common.Hash, error) {
+	for start := 0; ; start += governedPopWindow {
+		reply, err := conn.Eval(seqRangeScript, s.readyKey(), start, governedPopWindow)
+		if err != nil {
+			return nil, err
+		}
+
+		ids, _ := reply.([]interface{})
+		for _, v := range ids {
+			id, _ := v.(string)
+			msgId := common.HexToHash(id)
+
+			// msgs which could not be sent are popped anyway, then dropped by PopMsg
+			msg, err := s.msgStorage.GetMsg(msgId)
+			if err == nil && msg.Resp == nil && !s.governor.Allow(*msg.Req) {
+				continue
+			}
+
+			reply, err := conn.Eval(seqTakeScript, s.readyKey(), s.leasesKey(), id, s.leaseDeadline())
+			if err != nil {
+				return nil, err
+			}
+			if taken, _ := reply.(int64); taken == 0 {
+				// popped by other processes
+				continue
+			}
+
+			s.leased.Store(msgId, struct{}{})
+			return &msgId, nil
+		}
+
+		if len(ids) < governedPopWindow {
+			return nil, nil
+		}
+	}
+}
+
+func (s *RedisSequencer) count(script *redis.Script, key string) (int, error) {
+	conn, err := s.redisPool.Get(context.Background())
+	if err != nil {
+		return 0, err
+	}
+
+	reply, err := conn.Eval(script, key)
+	if err != nil {
+		return 0, err
+	}
+
+	count, _ := reply.(int64)
+	return int(count), nil
+}
+
+// run renews leases of this consumer, takes back expired leases of dead consumers,
+// and releases msgs whose predecessor was stored but never pushed.
+func (s *RedisSequencer) run() {
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-time.After(time.Second):
+		}
+
+		err := s.renewLeases()
+		if err != nil {
+			log.Warn("renew leases failed", "err", err)
+		}
+
+		err = s.reapLeases()
+		if err != nil {
+			log.Warn("reap leases failed", "err", err)
+		}
+
+		err = s.releaseBlocked()
+		if err != nil {
+			log.Warn("release blocked msgs failed", "err", err)
+		}
+	}
+}
+
+func (s *RedisSequencer) renewLeases() error {
+	conn, err := s.redisPool.Get(context.Background())
+	if err != nil {
+		return err
+	}
+
+	deadline := s.leaseDeadline()
+	s.leased.Range(func(key, value any) bool {
+		_, err = conn.Eval(seqRenewScript, s.leasesKey(), deadline, key.(common.Hash).Hex())
+		return err == nil
+	})
+
+	return err
+}
+
+func (s *RedisSequencer) reapLeases() error {
+	conn, err := s.redisPool.Get(context.Background())
+	if err != nil {
+		return err
+	}
+
+	reply, err := conn.Eval(seqReapScript, s.leasesKey(), s.readyKey(), s.scoresKey(), time.Now().UnixMilli())
+	if err != nil {
+		return err
+	}
+
+	if count, _ := reply.(int64); count > 0 {
+		log.Warn("leases of msgs expired, push them back", "count", count)
+	}
+
+	return nil
+}
+
+func (s *RedisSequencer) releaseBlocked() error {
+	conn, err := s.redisPool.Get(context.Background())
+	if err != nil {
+		return err
+	}
+
+	reply, err := conn.Eval(seqBlockedScript, s.blockedKey())
+	if err != nil {
+		return err
+	}
+
+	fields, _ := reply.([]interface{})
+	for i := 0; i+1 < len(fields); i += 2 {
+		id, _ := fields[i].(string)
+		pred, _ := fields[i+1].(string)
+
+		predId := common.HexToHash(pred)
+		if !s.msgStorage.HasMsg(predId) {
+			continue
+		}
+
+		_, err = conn.Eval(seqReleaseScript, s.queuedKey(), s.blockedKey(), s.waitersKey(predId), s.readyKey(),
+			s.scoresKey(), id, pred)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *RedisSequencer) leaseDeadline() int64 {
+	return time.Now().Add(s.leaseTimeout).UnixMilli()
+}
+
+// queuedKey is a set of all msgs in the sequencer, either ready, leased or blocked.
+func (s *RedisSequencer) queuedKey() string {
+	return fmt.Sprintf("msg-seq-chain-%s-queued", s.chainId.String())
+}
+
+// readyKey is a sorted set of msgs without pending predecessors, scored by priority.
+func (s *RedisSequencer) readyKey() string {
+	return fmt.Sprintf("msg-seq-chain-%s-ready", s.chainId.String())
+}
+
+// blockedKey is a hash of msgs waiting for their predecessors.
+func (s *RedisSequencer) blockedKey() string {
+	return fmt.Sprintf("msg-seq-chain-%s-blocked", s.chainId.String())
+}
+
+// leasesKey is a sorted set of popped msgs scored by lease deadline.
+func (s *RedisSequencer) leasesKey() string {
+	return fmt.Sprintf("msg-seq-chain-%s-leases", s.chainId.String())
+}
+
+// scoresKey is a hash of priority scores of queued msgs.
+func (s *RedisSequencer) scoresKey() string {
+	return fmt.Sprintf("msg-seq-chain-%s-scores", s.chainId.String())
+}
+
+func (s *RedisSequencer) waitersKey(msgId common.Hash) string {
+	return fmt.Sprintf("msg-seq-chain-%s-waiters-%s", s.chainId.String(), msgId.Hex())
+}
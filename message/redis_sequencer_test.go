@@ -0,0 +1,215 @@
+package message
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redsync/redsync/v4/redis"
+	"github.com/go-redsync/redsync/v4/redis/goredis/v9"
+	goredislib "github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestRedisPool(t *testing.T) redis.Pool {
+	server := miniredis.RunT(t)
+	return goredis.NewPool(goredislib.NewClient(&goredislib.Options{Addr: server.Addr()}))
+}
+
+// consumeMsgs pops msgs of the sequencer until it was closed.
+func consumeMsgs(s Sequencer) <-chan Request {
+	popped := make(chan Request, 10)
+	go func() {
+		defer close(popped)
+		for {
+			msg, err := s.PopMsg()
+			if err != nil {
+				return
+			}
+			popped <- msg
+		}
+	}()
+
+	return popped
+}
+
+// popMsgWithin returns false if no msg was popped in time.
+func popMsgWithin(popped <-chan Request, timeout time.Duration) (Request, bool) {
+	select {
+	case msg, ok := <-popped:
+		return msg, ok
+	case <-time.After(timeout):
+		return Request{}, false
+	}
+}
+
+func addTestMsgs(t *testing.T, storage Storage, reqs ...*Request) {
+	for _, req := range reqs {
+		err := storage.AddMsg(*req)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func Test_RedisSequencer_DependencyRelease(t *testing.T) {
+	storage, err := NewMemoryStorage()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sequencer := NewRedisSequencer(big.NewInt(1337), newTestRedisPool(t), storage)
+	defer sequencer.Close()
+	popped := consumeMsgs(sequencer)
+
+	pred := AssignMessageId(&Request{})
+	predId := pred.Id()
+	dependant := AssignMessageId(&Request{AfterMsg: &predId})
+	other := AssignMessageId(&Request{})
+	addTestMsgs(t, storage, pred, dependant, other)
+
+	for _, req := range []*Request{pred, dependant, other} {
+		err = sequencer.PushMsg(*req)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	queued, err := sequencer.QueuedMsgCount()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 1, queued)
+
+	msg, ok := popMsgWithin(popped, 3*time.Second)
+	assert.True(t, ok)
+	assert.Equal(t, pred.Id(), msg.Id())
+
+	// the dependant is blocked until its predecessor was acked
+	msg, ok = popMsgWithin(popped, 3*time.Second)
+	assert.True(t, ok)
+	assert.Equal(t, other.Id(), msg.Id())
+
+	_, ok = popMsgWithin(popped, time.Second)
+	assert.False(t, ok)
+
+	err = sequencer.AckMsg(pred.Id())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msg, ok = popMsgWithin(popped, 3*time.Second)
+	assert.True(t, ok)
+	assert.Equal(t, dependant.Id(), msg.Id())
+}
+
+func Test_RedisSequencer_LeaseExpiry(t *testing.T) {
+	storage, err := NewMemoryStorage()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pool := newTestRedisPool(t)
+	alive := NewRedisSequencer(big.NewInt(1337), pool, storage)
+	alive.SetLeaseTimeout(time.Second)
+	defer alive.Close()
+
+	dead := NewRedisSequencer(big.NewInt(1337), pool, storage)
+	dead.SetLeaseTimeout(time.Second)
+
+	renewed := AssignMessageId(&Request{})
+	expired := AssignMessageId(&Request{})
+	addTestMsgs(t, storage, renewed, expired)
+
+	err = alive.PushMsg(*renewed)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msg, err := alive.PopMsg()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, renewed.Id(), msg.Id())
+
+	err = alive.PushMsg(*expired)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msg, err = dead.PopMsg()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, expired.Id(), msg.Id())
+
+	// the consumer died without acking, so that its lease is reaped by others
+	dead.Close()
+	popped := consumeMsgs(alive)
+
+	msg, ok := popMsgWithin(popped, 5*time.Second)
+	assert.True(t, ok)
+	assert.Equal(t, expired.Id(), msg.Id())
+
+	// leases of a live consumer are renewed
+	_, ok = popMsgWithin(popped, 3*time.Second)
+	assert.False(t, ok)
+}
+
+func Test_Sequencer_RepushWithoutAck(t *testing.T) {
+	storage, err := NewMemoryStorage()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sequencers := map[string]Sequencer{
+		"memory": NewMemorySequencer(nil, storage, 10),
+		"redis":  NewRedisSequencer(big.NewInt(1337), newTestRedisPool(t), storage),
+	}
+
+	for name, sequencer := range sequencers {
+		t.Run(name, func(t *testing.T) {
+			defer sequencer.Close()
+			popped := consumeMsgs(sequencer)
+
+			held := AssignMessageId(&Request{})
+			heldId := held.Id()
+			dependant := AssignMessageId(&Request{AfterMsg: &heldId})
+			addTestMsgs(t, storage, held, dependant)
+
+			for _, req := range []*Request{held, dependant} {
+				err := sequencer.PushMsg(*req)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			msg, ok := popMsgWithin(popped, 3*time.Second)
+			assert.True(t, ok)
+			assert.Equal(t, held.Id(), msg.Id())
+
+			// e.g. held for funds, then pushed back
+			err := sequencer.PushMsg(*held)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			msg, ok = popMsgWithin(popped, 3*time.Second)
+			assert.True(t, ok)
+			assert.Equal(t, held.Id(), msg.Id())
+
+			_, ok = popMsgWithin(popped, time.Second)
+			assert.False(t, ok, "dependant is released before its predecessor was acked")
+
+			err = sequencer.AckMsg(held.Id())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			msg, ok = popMsgWithin(popped, 3*time.Second)
+			assert.True(t, ok)
+			assert.Equal(t, dependant.Id(), msg.Id())
+		})
+	}
+}
@@ -0,0 +1,519 @@
+package message
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/go-redsync/redsync/v4/redis"
+)
+
+var _ Storage = &RedisStorage{}
+
+var (
+	// KEYS: msg, index; ARGV: req, status, created, cursor
+	addMsgScript = redis.NewScript(2, `
+if redis.call("EXISTS", KEYS[1]) == 1 then
+	return redis.error_reply("duplicated msg not allowed")
+end
+redis.call("HSET", KEYS[1], "req", ARGV[1], "status", ARGV[2], "created", ARGV[3])
+redis.call("ZADD", KEYS[2], 0, ARGV[4])
+return 1
+`)
+
+	// KEYS: msg
+	getMsgScript = redis.NewScript(1, `return redis.call("HGETALL", KEYS[1])`)
+
+	// KEYS: msg, index; ARGV: cursor, field, value, ...
+	updateMsgScript = redis.NewScript(2, `
+redis.call("DEL", KEYS[1])
+redis.call("HSET", KEYS[1], unpack(ARGV, 2))
+redis.call("ZADD", KEYS[2], 0, ARGV[1])
+return 1
+`)
+
+	// KEYS: index; ARGV: min, max, count
+	listMsgsScript = redis.NewScript(1, `return redis.call("ZRANGEBYLEX", KEYS[1], ARGV[1], ARGV[2], "LIMIT", 0, ARGV[3])`)
+
+	// KEYS: msg; ARGV: status, receipt (removed if empty)
+	updateReceiptAndStatusScript = redis.NewScript(1, `
+if redis.call("EXISTS", KEYS[1]) == 0 then
+	return redis.error_reply("not found")
+end
+if ARGV[2] == "" then
+	redis.call("HDEL", KEYS[1], "receipt")
+else
+	redis.call("HSET", KEYS[1], "receipt", ARGV[2])
+end
+redis.call("HSET", KEYS[1], "status", ARGV[1])
+return 1
+`)
+
+	// KEYS: msg; ARGV: to, from...
+	transitStatusScript = redis.NewScript(1, `
+if redis.call("EXISTS", KEYS[1]) == 0 then
+	return redis.error_reply("not found")
+end
+local status = redis.call("HGET", KEYS[1], "status")
+for i = 2, #ARGV do
+	if ARGV[i] == status then
+		redis.call("HSET", KEYS[1], "status", ARGV[1])
+		return "OK"
+	end
+end
+return status
+`)
+
+	// KEYS: msg; ARGV: field, value, allowOverwrite
+	updateFieldScript = redis.NewScript(1, `
+if redis.call("EXISTS", KEYS[1]) == 0 then
+	return redis.error_reply("not found")
+end
+if ARGV[3] == "0" and redis.call("HEXISTS", KEYS[1], ARGV[1]) == 1 then
+	return redis.error_reply("same msg not allowed updating " .. ARGV[1] .. " twice")
+end
+redis.call("HSET", KEYS[1], ARGV[1], ARGV[2])
+return 1
+`)
+)
+
+var (
+	// KEYS: series; ARGV: rootId
+	getSeriesScript = redis.NewScript(1, `return redis.call("HGET", KEYS[1], ARGV[1])`)
+
+	// KEYS: series
+	listSeriesScript = redis.NewScript(1, `return redis.call("HVALS", KEYS[1])`)
+
+	// KEYS: series; ARGV: rootId, series
+	updateSeriesScript = redis.NewScript(1, `return redis.call("HSET", KEYS[1], ARGV[1], ARGV[2])`)
+)
+
+// RedisStorage shares messages between processes, and keeps them after restart.
+// Every message is stored as a hash, so that each field could be updated atomically.
+type RedisStorage struct {
+	chainId   *big.Int
+	redisPool redis.Pool
+}
+
+func NewRedisStorage(chainId *big.Int, pool redis.Pool) *RedisStorage {
+	return &RedisStorage{
+		chainId:   chainId,
+		redisPool: pool,
+	}
+}
+
+func (s *RedisStorage) AddMsg(req Request) error {
+	log.Debug("RedisStorage AddMsg", "req", req)
+
+	err := checkPersisted(&req)
+	if err != nil {
+		return err
+	}
+
+	conn, err := s.redisPool.Get(context.Background())
+	if err != nil {
+		return err
+	}
+
+	reqBytes, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+
+	msg := Message{Req: &req, CreatedAt: time.Now().UnixNano()}
+
+	_, err = conn.Eval(addMsgScript, s.msgKey(req.id), s.indexKey(), string(reqBytes),
+		strconv.Itoa(int(MessageStatusSubmitted)), strconv.FormatInt(msg.CreatedAt, 10), msgCursor(msg))
+	return err
+}
+
+func (s *RedisStorage) HasMsg(msgId common.Hash) bool {
+	_, err := s.GetMsg(msgId)
+	return err == nil
+}
+
+func (s *RedisStorage) GetMsg(msgId common.Hash) (Message, error) {
+	conn, err := s.redisPool.Get(context.Background())
+	if err != nil {
+		return Message{}, err
+	}
+
+	reply, err := conn.Eval(getMsgScript, s.msgKey(msgId))
+	if err != nil {
+		return Message{}, err
+	}
+
+	fields, ok := reply.([]interface{})
+	if !ok || len(fields) == 0 {
+		return Message{}, fmt.Errorf("not found")
+	}
+
+	return decodeMsgFields(fields)
+}
+
+func (s *RedisStorage) UpdateMsg(msg Message) error {
+	err := checkPersisted(msg.Req)
+	if err != nil {
+		return err
+	}
+
+	conn, err := s.redisPool.Get(context.Background())
+	if err != nil {
+		return err
+	}
+
+	args, err := encodeMsgFields(msg)
+	if err != nil {
+		return err
+	}
+
+	_, err = conn.Eval(updateMsgScript, append([]interface{}{s.msgKey(msg.Req.id), s.indexKey(), msgCursor(msg)}, args...)...)
+	return err
+}
+
+func (s *RedisStorage) UpdateRequest(msgId common.Hash, req Request) error {
+	req.id = msgId
+	err := checkPersisted(&req)
+	if err != nil {
+		return err
+	}
+
+	reqBytes, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+
+	return s.updateField(msgId, "req", string(reqBytes), true)
+}
+
+func (s *RedisStorage) UpdateResponse(msgId common.Hash, resp Response) error {
+	log.Debug("RedisStorage UpdateResponse", "msgId", msgId.Hex(), "resp", resp)
+
+	respBytes, err := json.Marshal(resp)
+	if err != nil {
+		return err
+	}
+
+	return s.updateField(msgId, "resp", string(respBytes), false)
+}
+
+func (s *RedisStorage) UpdateReceipt(msgId common.Hash, receipt Receipt) error {
+	log.Debug("RedisStorage UpdateReceipt", "msgId", msgId.Hex(),
+		"txHash", receipt.TxReceipt.TxHash.Hex(), "receipt", receipt)
+
+	receiptBytes, err := json.Marshal(receipt)
+	if err != nil {
+		return err
+	}
+
+	return s.updateField(msgId, "receipt", string(receiptBytes), true)
+}
+
+func (s *RedisStorage) UpdateReceiptAndStatus(msgId common.Hash, receipt *Receipt, status MessageStatus) error {
+	log.Debug("RedisStorage UpdateReceiptAndStatus", "msgId", msgId.Hex(), "receipt", receipt, "status", status)
+
+	receiptValue := ""
+	if receipt != nil {
+		receiptBytes, err := json.Marshal(receipt)
+		if err != nil {
+			return err
+		}
+		receiptValue = string(receiptBytes)
+	}
+
+	conn, err := s.redisPool.Get(context.Background())
+	if err != nil {
+		return err
+	}
+
+	_, err = conn.Eval(updateReceiptAndStatusScript, s.msgKey(msgId), strconv.Itoa(int(status)), receiptValue)
+	return err
+}
+
+func (s *RedisStorage) UpdateMsgStatus(msgId common.Hash, status MessageStatus) error {
+	log.Debug("RedisStorage UpdateMsgStatus", "msgId", msgId.Hex(), "status", status)
+
+	return s.updateField(msgId, "status", strconv.Itoa(int(status)), true)
+}
+
+func (s *RedisStorage) TransitMsgStatus(msgId common.Hash, from []MessageStatus, to MessageStatus) error {
+	log.Debug("RedisStorage TransitMsgStatus", "msgId", msgId.Hex(), "from", from, "to", to)
+
+	conn, err := s.redisPool.Get(context.Background())
+	if err != nil {
+		return err
+	}
+
+	args := []interface{}{s.msgKey(msgId), strconv.Itoa(int(to))}
+	for _, status := range from {
+		args = append(args, strconv.Itoa(int(status)))
+	}
+
+	reply, err := conn.Eval(transitStatusScript, args...)
+	if err != nil {
+		return err
+	}
+
+	if current, _ := reply.(string); current != "OK" {
+		return fmt.Errorf("%w: %v", ErrStatusConflict, current)
+	}
+
+	return nil
+}
+
+func (s *RedisStorage) GetNonce(msgId common.Hash) (nonce uint64, err error) {
+	msg, err := s.GetMsg(msgId)
+	if err != nil {
+		return
+	}
+
+	if msg.Resp == nil || msg.Resp.Tx == nil {
+		return 0, fmt.Errorf("no nonce assigned")
+	}
+
+	return msg.Resp.Tx.Nonce(), nil
+}
+
+func (s *RedisStorage) ListMsgs(filter MsgFilter) (msgs []Message, nextCursor string, err error) {
+	conn, err := s.redisPool.Get(context.Background())
+	if err != nil {
+		return nil, "", err
+	}
+
+	start, end := filter.cursorRange()
+	min, max := "-", "+"
+	if start != "" {
+		min = "(" + start
+	}
+	if end != "" {
+		max = "(" + end
+	}
+
+	for {
+		reply, err := conn.Eval(listMsgsScript, s.indexKey(), min, max, filter.limit())
+		if err != nil {
+			return nil, "", err
+		}
+
+		cursors, _ := reply.([]interface{})
+		if len(cursors) == 0 {
+			return msgs, "", nil
+		}
+
+		for _, c := range cursors {
+			cursor, _ := c.(string)
+			msgId := cursor[strings.LastIndex(cursor, "-")+1:]
+
+			msg, err := s.GetMsg(common.HexToHash(msgId))
+			if err != nil {
+				return nil, "", err
+			}
+
+			if !filter.Match(msg) {
+				continue
+			}
+
+			if len(msgs) == filter.limit() {
+				return msgs, msgCursor(msgs[len(msgs)-1]), nil
+			}
+
+			msgs = append(msgs, msg)
+		}
+
+		min = "(" + cursors[len(cursors)-1].(string)
+	}
+}
+
+func (s *RedisStorage) GetSeries(rootId common.Hash) (Series, error) {
+	conn, err := s.redisPool.Get(context.Background())
+	if err != nil {
+		return Series{}, err
+	}
+
+	reply, err := conn.Eval(getSeriesScript, s.seriesKey(), rootId.Hex())
+	if err != nil {
+		return Series{}, err
+	}
+
+	value, ok := reply.(string)
+	if !ok {
+		return Series{}, ErrSeriesNotFound
+	}
+
+	var series Series
+	err = json.Unmarshal([]byte(value), &series)
+	return series, err
+}
+
+func (s *RedisStorage) ListSeries() (list []Series, err error) {
+	conn, err := s.redisPool.Get(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	reply, err := conn.Eval(listSeriesScript, s.seriesKey())
+	if err != nil {
+		return nil, err
+	}
+
+	values, _ := reply.([]interface{})
+	for _, v := range values {
+		value, _ := v.(string)
+
+		var series Series
+		err = json.Unmarshal([]byte(value), &series)
+		if err != nil {
+			return nil, err
+		}
+
+		list = append(list, series)
+	}
+
+	sortSeries(list)
+	return list, nil
+}
+
+func (s *RedisStorage) UpdateSeries(series Series) error {
+	log.Debug("RedisStorage UpdateSeries", "rootId", series.RootId.Hex(), "status", series.Status)
+
+	conn, err := s.redisPool.Get(context.Background())
+	if err != nil {
+		return err
+	}
+
+	value, err := json.Marshal(series)
+	if err != nil {
+		return err
+	}
+
+	_, err = conn.Eval(updateSeriesScript, s.seriesKey(), series.RootId.Hex(), string(value))
+	return err
+}
+
+func (s *RedisStorage) updateField(msgId common.Hash, field, value string, allowOverwrite bool) error {
+	conn, err := s.redisPool.Get(context.Background())
+	if err != nil {
+		return err
+	}
+
+	overwrite := "0"
+	if allowOverwrite {
+		overwrite = "1"
+	}
+
+	_, err = conn.Eval(updateFieldScript, s.msgKey(msgId), field, value, overwrite)
+	return err
+}
+
+func (s *RedisStorage) msgKey(msgId common.Hash) string {
+	return fmt.Sprintf("msg-chain-%s-id-%s", s.chainId.String(), msgId.Hex())
+}
+
+// indexKey is a sorted set of msg cursors, so msgs are iterated in order of creation.
+func (s *RedisStorage) indexKey() string {
+	return fmt.Sprintf("msg-index-chain-%s", s.chainId.String())
+}
+
+// seriesKey is a hash of all series.
+func (s *RedisStorage) seriesKey() string {
+	return fmt.Sprintf("series-chain-%s", s.chainId.String())
+}
+
+// encodeMsgFields flattens the message into field-value pairs of a hash.
+func encodeMsgFields(msg Message) ([]interface{}, error) {
+	reqBytes, err := json.Marshal(msg.Req)
+	if err != nil {
+		return nil, err
+	}
+
+	args := []interface{}{
+		"req", string(reqBytes),
+		"status", strconv.Itoa(int(msg.Status)),
+		"created", strconv.FormatInt(msg.CreatedAt, 10),
+	}
+
+	if msg.Root != nil {
+		args = append(args, "root", msg.Root.Hex())
+	}
+
+	if msg.Parent != nil {
+		args = append(args, "parent", msg.Parent.Hex())
+	}
+
+	if msg.Resp != nil {
+		respBytes, err := json.Marshal(msg.Resp)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, "resp", string(respBytes))
+	}
+
+	if msg.Receipt != nil {
+		receiptBytes, err := json.Marshal(msg.Receipt)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, "receipt", string(receiptBytes))
+	}
+
+	if msg.SignedTx != nil {
+		txBytes, err := json.Marshal(msg.SignedTx)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, "signedTx", string(txBytes))
+	}
+
+	return args, nil
+}
+
+func decodeMsgFields(fields []interface{}) (msg Message, err error) {
+	for i := 0; i+1 < len(fields); i += 2 {
+		field, _ := fields[i].(string)
+		value, _ := fields[i+1].(string)
+
+		switch field {
+		case "req":
+			msg.Req = &Request{}
+			err = json.Unmarshal([]byte(value), msg.Req)
+		case "status":
+			var status int
+			status, err = strconv.Atoi(value)
+			msg.Status = MessageStatus(status)
+		case "created":
+			msg.CreatedAt, err = strconv.ParseInt(value, 10, 64)
+		case "root":
+			root := common.HexToHash(value)
+			msg.Root = &root
+		case "parent":
+			parent := common.HexToHash(value)
+			msg.Parent = &parent
+		case "resp":
+			msg.Resp = &Response{}
+			err = json.Unmarshal([]byte(value), msg.Resp)
+		case "receipt":
+			msg.Receipt = &Receipt{}
+			err = json.Unmarshal([]byte(value), msg.Receipt)
+		case "signedTx":
+			msg.SignedTx = &types.Transaction{}
+			err = json.Unmarshal([]byte(value), msg.SignedTx)
+		}
+
+		if err != nil {
+			return Message{}, fmt.Errorf("decode msg field %s failed: %v", field, err)
+		}
+	}
+
+	if msg.Req == nil {
+		return Message{}, fmt.Errorf("not found")
+	}
+
+	return msg, nil
+}
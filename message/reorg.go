@@ -0,0 +1,165 @@
+package message
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+const (
+	// DefaultFinalityDepth is how many blocks on top of the msg, after which it could not be reorged,
+	// it's used on chains without the finality tags.
+	DefaultFinalityDepth = uint64(64)
+	// reorgCheckInterval is how often the chain head is polled for checking receipts of watched msgs.
+	reorgCheckInterval = time.Second
+)
+
+// SetFinalityDepth sets how many blocks on top of on-chain msgs they are final, if the finality tag is not available.
+func (b *SimpleBroadcaster) SetFinalityDepth(depth uint64) {
+	b.finalityDepth.Store(depth)
+}
+
+// WatchMsg keeps checking the receipt of the on-chain msg until it's MessageStatusFinalized.
+// If its tx was dropped by a reorg, the msg goes back to MessageStatusInflight,
+// and is rebroadcasted and protected again.
+func (b *SimpleBroadcaster) WatchMsg(ctx context.Context, msgId common.Hash) {
+	b.watched.Store(msgId, ctx)
+
+	b.watchOnce.Do(func() {
+		go b.watch()
+	})
+}
+
+// Close stops watching msgs, msgs on-chain are no longer checked for reorgs.
+func (b *SimpleBroadcaster) Close() {
+	b.cancel()
+}
+
+func (b *SimpleBroadcaster) watch() {
+	var lastBlock uint64
+
+	for {
+		select {
+		case <-b.ctx.Done():
+			return
+		case <-time.After(reorgCheckInterval):
+		}
+
+		if !b.watching() {
+			continue
+		}
+
+		block, err := b.msgManager.BlockNumber(b.ctx)
+		if err != nil {
+			log.Warn("get block number for checking reorgs failed", "err", err)
+			continue
+		}
+
+		if block == lastBlock {
+			continue
+		}
+		lastBlock = block
+
+		final, err := b.finalizedBlock(b.ctx, block)
+		if err != nil {
+			log.Warn("get finalized block failed", "err", err)
+			continue
+		}
+
+		b.watched.Range(func(key, value any) bool {
+			msgId, ctx := key.(common.Hash), value.(context.Context)
+
+			select {
+			case <-ctx.Done():
+				b.watched.Delete(msgId)
+				return true
+			default:
+			}
+
+			done, err := b.checkReorg(ctx, msgId, final)
+			if err != nil {
+				log.Warn("check reorg of msg failed", "msgId", msgId.Hex(), "err", err)
+				return true
+			}
+
+			if done {
+				b.watched.Delete(msgId)
+			}
+
+			return true
+		})
+	}
+}
+
+func (b *SimpleBroadcaster) watching() (ok bool) {
+	b.watched.Range(func(_, _ any) bool {
+		ok = true
+		return false
+	})
+
+	return
+}
+
+// checkReorg reconciles the receipt of the msg with the canonical chain and finalizes it,
+// done is true if the msg no longer needs watching.
+func (b *SimpleBroadcaster) checkReorg(ctx context.Context, msgId common.Hash, final uint64) (done bool, err error) {
+	msg, err := b.msgManager.GetMsg(msgId)
+	if err != nil {
+		return false, err
+	}
+
+	if msg.Status != MessageStatusOnChain || msg.Receipt == nil {
+		return true, nil
+	}
+
+	recorded := msg.Receipt.TxReceipt
+	txReceipt, err := b.msgManager.TransactionReceipt(ctx, recorded.TxHash)
+	if errors.Is(err, ethereum.NotFound) {
+		log.Warn("msg was dropped by reorg", "msgId", msgId.Hex(), "txHash", recorded.TxHash.Hex(),
+			"blockNumber", recorded.BlockNumber, "blockHash", recorded.BlockHash.Hex())
+
+		err = b.msgManager.UpdateReceiptAndStatus(msgId, nil, MessageStatusInflight)
+		if err != nil {
+			return false, err
+		}
+
+		resp := b.msgManager.RebroadcastMsg(ctx, msgId)
+		if resp.Err != nil {
+			// the tx will be replaced while protecting
+			log.Warn("rebroadcast msg dropped by reorg failed", "msgId", msgId.Hex(), "err", resp.Err)
+		}
+
+		go b.protect(ctx, msgId)
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if txReceipt.BlockHash != recorded.BlockHash {
+		log.Warn("msg was mined again by reorg", "msgId", msgId.Hex(), "txHash", txReceipt.TxHash.Hex(),
+			"blockNumber", txReceipt.BlockNumber, "blockHash", txReceipt.BlockHash.Hex(), "oldBlockHash", recorded.BlockHash.Hex())
+
+		err = b.msgManager.UpdateReceipt(msgId, Receipt{Id: msgId, TxReceipt: txReceipt})
+		if err != nil {
+			return false, err
+		}
+	}
+
+	if txReceipt.BlockNumber.Uint64() > final {
+		return false, nil
+	}
+
+	log.Info("msg finalized", "msgId", msgId.Hex(), "txHash", txReceipt.TxHash.Hex(), "blockNumber", txReceipt.BlockNumber)
+
+	err = b.msgManager.UpdateMsgStatus(msgId, MessageStatusFinalized)
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
@@ -0,0 +1,82 @@
+package message
+
+import (
+	"time"
+
+	"github.com/ivanzzeth/ethclient/common/cron"
+)
+
+// Recurring reports whether msgs will be created by the task periodically.
+func (q *Request) Recurring() bool {
+	return q.Interval != 0 || q.Cron != ""
+}
+
+// FirstRun returns the start time of the first msg of the recurring task,
+// ok is false if the task was over before it started.
+// Cron tasks start at the first time of the schedule, not immediately.
+func (q *Request) FirstRun(now int64) (first int64, ok bool, err error) {
+	if q.Cron == "" {
+		first = now
+	} else {
+		schedule, err := q.cronSchedule()
+		if err != nil {
+			return 0, false, err
+		}
+
+		firstTime := schedule.Next(time.Unix(0, now))
+		if firstTime.IsZero() {
+			return 0, false, nil
+		}
+		first = firstTime.UnixNano()
+	}
+
+	if q.EndTime != 0 && first > q.EndTime {
+		return 0, false, nil
+	}
+
+	return first, true, nil
+}
+
+// NextRun returns the start time of the msg after this one of the recurring task,
+// ok is false if the task was over.
+func (q *Request) NextRun(now int64) (next int64, ok bool, err error) {
+	if q.MaxRuns == 1 {
+		return 0, false, nil
+	}
+
+	if q.Cron != "" {
+		schedule, err := q.cronSchedule()
+		if err != nil {
+			return 0, false, err
+		}
+
+		// following the schedule instead of now, so that it does not drift,
+		// but runs missed (e.g. series paused) are not caught up
+		nextTime := schedule.Next(time.Unix(0, max(q.StartTime, now)))
+		if nextTime.IsZero() {
+			return 0, false, nil
+		}
+		next = nextTime.UnixNano()
+	} else {
+		next = now + int64(q.Interval)
+	}
+
+	if q.EndTime != 0 && next > q.EndTime {
+		return 0, false, nil
+	}
+
+	return next, true, nil
+}
+
+func (q *Request) cronSchedule() (*cron.Schedule, error) {
+	loc := time.UTC
+	if q.CronTimeZone != "" {
+		var err error
+		loc, err = time.LoadLocation(q.CronTimeZone)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return cron.Parse(q.Cron, loc)
+}
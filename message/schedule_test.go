@@ -0,0 +1,65 @@
+package message
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_NextRun(t *testing.T) {
+	loc, err := time.LoadLocation("Asia/Shanghai")
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2024, 1, 1, 8, 30, 0, 0, loc).UnixNano()
+
+	req := &Request{Cron: "0 */4 * * *", CronTimeZone: "Asia/Shanghai"}
+	first, ok, err := req.FirstRun(now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, ok)
+	assert.Equal(t, time.Date(2024, 1, 1, 12, 0, 0, 0, loc).UnixNano(), first)
+
+	// the next run follows the schedule even if the msg was sent late
+	req.StartTime = first
+	next, ok, err := req.NextRun(first + int64(10*time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, ok)
+	assert.Equal(t, time.Date(2024, 1, 1, 16, 0, 0, 0, loc).UnixNano(), next)
+
+	// runs missed while the series was paused are not caught up
+	next, ok, err = req.NextRun(first + int64(9*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, ok)
+	assert.Equal(t, time.Date(2024, 1, 1, 24, 0, 0, 0, loc).UnixNano(), next)
+
+	req.EndTime = time.Date(2024, 1, 1, 16, 0, 0, 0, loc).UnixNano() - 1
+	_, ok, err = req.NextRun(first)
+	assert.Nil(t, err)
+	assert.False(t, ok, "no more runs after EndTime")
+
+	req.EndTime = first - 1
+	_, ok, err = req.FirstRun(now)
+	assert.Nil(t, err)
+	assert.False(t, ok, "no runs if EndTime is before the first one")
+
+	req = &Request{Interval: time.Second, EndTime: now - 1}
+	_, ok, err = req.FirstRun(now)
+	assert.Nil(t, err)
+	assert.False(t, ok, "no runs if EndTime passed")
+
+	req = &Request{Interval: time.Second, MaxRuns: 1}
+	_, ok, err = req.NextRun(now)
+	assert.Nil(t, err)
+	assert.False(t, ok, "no more runs after MaxRuns")
+
+	req = &Request{Cron: "invalid"}
+	_, _, err = req.FirstRun(now)
+	assert.Error(t, err)
+}
@@ -1,11 +1,32 @@
 package message
 
+import (
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// DefaultPriorityAging raises the priority of a waiting msg by 1 every period,
+// so that msgs with low priority are not starved.
+const DefaultPriorityAging = 10 * time.Second
+
 type Sequencer interface {
+	// PushMsg queues the msg, a popped msg pushed again before being acked keeps its dependants waiting.
 	PushMsg(msg Request) error
 	// block if no any msgs return
 	PopMsg() (Request, error)
 	PeekMsg() (Request, error)
+	// AckMsg tells the sequencer the popped msg was consumed, so its dependants could be popped.
+	AckMsg(msgId common.Hash) error
 	QueuedMsgCount() (int, error)
 	PendingMsgCount() (int, error)
+	// SetGovernor limits msgs popped, no limits if nil.
+	SetGovernor(governor Governor)
 	Close()
 }
+
+// priorityScore ranks ready msgs, the lower the earlier.
+// Waiting for an aging period is worth as much as 1 priority.
+func priorityScore(req Request, pushedAt time.Time, aging time.Duration) int64 {
+	return pushedAt.UnixMilli() - int64(req.Priority)*aging.Milliseconds()
+}
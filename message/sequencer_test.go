@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
 )
 
 func Test_Sequencer(t *testing.T) {
@@ -149,3 +150,77 @@ func Test_Sequencer(t *testing.T) {
 		t.Logf("Got sequence: %v", got)
 	}
 }
+
+func Test_SequencerPriority(t *testing.T) {
+	storage, err := NewMemoryStorage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sequencer := NewMemorySequencer(nil, storage, 10)
+	sequencer.SetDeadlineOrdering(true)
+
+	now := time.Now().UnixNano()
+	inputs := []Request{
+		{id: common.HexToHash("0x1")},
+		{id: common.HexToHash("0x2"), Priority: 1, ExpirationTime: now + int64(time.Hour)},
+		{id: common.HexToHash("0x3"), Priority: 1, ExpirationTime: now + int64(time.Minute)},
+		{id: common.HexToHash("0x4"), Priority: 2},
+		{id: common.HexToHash("0x5")},
+	}
+
+	for _, req := range inputs {
+		err = storage.AddMsg(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = sequencer.PushMsg(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	time.Sleep(500 * time.Millisecond)
+
+	got := []common.Hash{}
+	for range inputs {
+		req, err := sequencer.PopMsg()
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, req.Id())
+	}
+
+	// the first msg may be picked before others were pushed
+	want := []common.Hash{inputs[3].Id(), inputs[2].Id(), inputs[1].Id(), inputs[0].Id(), inputs[4].Id()}
+	if got[0] == inputs[0].Id() {
+		want = append([]common.Hash{inputs[0].Id()}, append(want[:3], inputs[4].Id())...)
+	}
+	assert.Equal(t, want, got)
+}
+
+func Test_PriorityScoreAging(t *testing.T) {
+	now := time.Now()
+	urgent := priorityScore(Request{Priority: 1}, now, time.Second)
+	routine := priorityScore(Request{}, now.Add(-2*time.Second), time.Second)
+
+	assert.Less(t, routine, urgent, "routine msg waiting longer than aging must be popped first")
+}
+
+func Test_PriorityLevelDeadline(t *testing.T) {
+	s := &MemorySequencer{deadlineOrdering: true}
+
+	now := time.Now()
+	aging := time.Second
+	push := func(req Request, pushedAt time.Time) Request {
+		score := priorityScore(req, pushedAt, aging)
+		s.queued.Store(req.Id(), queuedMsg{req: req, score: score, level: priorityLevel(score, aging)})
+		return req
+	}
+
+	routine := push(Request{id: common.HexToHash("0x1"), ExpirationTime: now.Add(time.Minute).UnixNano()}, now)
+	urgent := push(Request{id: common.HexToHash("0x2"), Priority: 2}, now)
+	aged := push(Request{id: common.HexToHash("0x3"), ExpirationTime: now.Add(time.Minute).UnixNano()}, now.Add(-3*aging))
+
+	assert.True(t, s.less(urgent.Id(), routine.Id()), "deadline must not beat higher priority")
+	assert.True(t, s.less(aged.Id(), urgent.Id()), "aged msg must be raised over higher priority")
+}
@@ -0,0 +1,73 @@
+package message
+
+import (
+	"bytes"
+	"errors"
+	"slices"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+var (
+	// ErrSeriesStopped is the error of msgs cancelled because their series was stopped.
+	ErrSeriesStopped = errors.New("series stopped")
+	// ErrSeriesNotFound is returned by storages if the series was never registered.
+	ErrSeriesNotFound = errors.New("series not found")
+)
+
+type SeriesStatus uint8
+
+const (
+	SeriesStatusActive SeriesStatus = iota + 1
+	SeriesStatusPaused
+	SeriesStatusStopped
+)
+
+func (s SeriesStatus) String() string {
+	switch s {
+	case SeriesStatusActive:
+		return "active"
+	case SeriesStatusPaused:
+		return "paused"
+	case SeriesStatusStopped:
+		return "stopped"
+	default:
+		return "unknown"
+	}
+}
+
+// Series is the recurring task of a msg with Interval or Cron,
+// and the msg is the root of all msgs created by the task.
+type Series struct {
+	RootId    common.Hash
+	Status    SeriesStatus
+	CreatedAt int64 // unix nano
+	UpdatedAt int64 // unix nano
+}
+
+// SeriesRoot returns the root id of the series which the msg belongs to,
+// ok is false if the msg is not recurring.
+func (m *Message) SeriesRoot() (rootId common.Hash, ok bool) {
+	if m.Root != nil {
+		return *m.Root, true
+	}
+
+	if m.Req.Recurring() {
+		return m.Id(), true
+	}
+
+	return common.Hash{}, false
+}
+
+func sortSeries(list []Series) {
+	slices.SortFunc(list, func(a, b Series) int {
+		if a.CreatedAt != b.CreatedAt {
+			if a.CreatedAt < b.CreatedAt {
+				return -1
+			}
+			return 1
+		}
+
+		return bytes.Compare(a.RootId.Bytes(), b.RootId.Bytes())
+	})
+}
@@ -17,22 +17,41 @@ import (
 
 var _ Manager = (*SimpleManager)(nil)
 
+// msgPollInterval is how often the storage is polled while waiting for msgs, it's cheaper than polling the node.
+const msgPollInterval = 100 * time.Millisecond
+
+// GasEstimator estimates gas limits of msgs without Gas, the result is used as is.
+type GasEstimator interface {
+	EstimateGas(ctx context.Context, msg ethereum.CallMsg) (uint64, error)
+}
+
 type SimpleManager struct {
-	backend ethBackend
-	nm      nonce.Manager
+	backend      ethBackend
+	nm           nonce.Manager
+	gasEstimator GasEstimator
+	pools        *senderPools
+	inFlights    *inFlightIndex
 	account.Registry
 	Storage
 }
 
 func NewSimpleManager(backend ethBackend, nm nonce.Manager, accountRegistry account.Registry, storage Storage) *SimpleManager {
 	return &SimpleManager{
-		backend:  backend,
-		nm:       nm,
-		Registry: accountRegistry,
-		Storage:  storage,
+		backend:      backend,
+		nm:           nm,
+		gasEstimator: nm,
+		pools:        newSenderPools(),
+		inFlights:    newInFlightIndex(),
+		Registry:     accountRegistry,
+		Storage:      storage,
 	}
 }
 
+// SetGasEstimator replaces the nonce manager estimating gas limits of msgs.
+func (c *SimpleManager) SetGasEstimator(estimator GasEstimator) {
+	c.gasEstimator = estimator
+}
+
 func (c *SimpleManager) CallAndSendMsg(ctx context.Context, msg Request) (resp Response) {
 	// err := c.AddMsg(msg)
 	// if err != nil {
@@ -110,6 +129,76 @@ func (m SimpleManager) ReplaceMsgWithHigherGasPrice(ctx context.Context, msgId c
 	return
 }
 
+func (m SimpleManager) CancelMsg(ctx context.Context, msgId common.Hash) (resp Response) {
+	log.Info("cancel message", "msgId", msgId)
+	resp.Id = msgId
+
+	signedTx, err := m.cancelMsg(ctx, msgId)
+	if err != nil {
+		resp.Err = err
+		return
+	}
+
+	resp = Response{
+		Id:  msgId,
+		Tx:  signedTx,
+		Err: err,
+	}
+
+	return
+}
+
+func (m SimpleManager) ReplaceCancellation(ctx context.Context, msgId common.Hash, cancelTx *types.Transaction) (resp Response) {
+	log.Info("replace cancellation with higher gas price", "msgId", msgId, "txHash", cancelTx.Hash().Hex())
+	resp.Id = msgId
+
+	msg, err := m.GetMsg(msgId)
+	if err != nil {
+		resp.Err = err
+		return
+	}
+
+	resp.Tx, resp.Err = m.replaceWithCancellation(ctx, msg, cancelTx)
+	return
+}
+
+func (m SimpleManager) RebroadcastMsg(ctx context.Context, msgId common.Hash) (resp Response) {
+	log.Info("rebroadcast message", "msgId", msgId)
+	resp.Id = msgId
+
+	msg, err := m.GetMsg(msgId)
+	if err != nil {
+		resp.Err = err
+		return
+	}
+
+	if msg.Resp == nil || msg.Resp.Tx == nil {
+		resp.Err = fmt.Errorf("no nonce assigned")
+		return
+	}
+
+	resp = Response{Id: msgId, Tx: msg.Resp.Tx}
+	// e.g. back in flight after a reorg
+	m.inFlights.track(msg.Req.From, msgId, msg.Resp.Tx.Cost())
+
+	_, _, err = m.backend.TransactionByHash(ctx, msg.Resp.Tx.Hash())
+	if err == nil {
+		// still in the mempool or mined again
+		return
+	}
+
+	err = m.backend.SendTransaction(ctx, msg.Resp.Tx)
+	if err != nil {
+		resp.Err = fmt.Errorf("SendTransaction err: %v", err)
+		return
+	}
+
+	log.Info("Rebroadcast Message successfully", "msgId", msgId, "txHash", msg.Resp.Tx.Hash().Hex(),
+		"from", msg.Req.From.Hex(), "nonce", msg.Resp.Tx.Nonce())
+
+	return
+}
+
 // func (m SimpleManager) ReplaceMsg(msgId common.Hash, newMsg Request) (resp Response) {
 // 	return Response{}
 // }
@@ -136,6 +225,18 @@ func (c SimpleManager) MessageToTransactOpts(ctx context.Context, msg Request) (
 	return auth, nil
 }
 
+func (c SimpleManager) BlockNumber(ctx context.Context) (uint64, error) {
+	return c.backend.BlockNumber(ctx)
+}
+
+func (c SimpleManager) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	return c.backend.HeaderByNumber(ctx, number)
+}
+
+func (c SimpleManager) TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
+	return c.backend.TransactionReceipt(ctx, txHash)
+}
+
 func (c SimpleManager) WaitTxReceipt(txHash common.Hash, confirmations uint64, timeout time.Duration) (*types.Receipt, bool) {
 	startTime := time.Now()
 	retryCount := 0
@@ -183,13 +284,13 @@ func (c SimpleManager) WaitMsgResponse(msgId common.Hash, timeout time.Duration)
 		msg, err := c.GetMsg(msgId)
 		if err != nil {
 			// log.Debug("WaitMsgResponse GetMsg failed", "err", err)
-			time.Sleep(1 * time.Second)
+			time.Sleep(msgPollInterval)
 			continue
 		}
 
 		if msg.Resp == nil {
 			// log.Debug("WaitMsgResponse msg.Resp is nil")
-			time.Sleep(1 * time.Second)
+			time.Sleep(msgPollInterval)
 			continue
 		}
 
@@ -208,7 +309,7 @@ func (c SimpleManager) WaitMsgReceipt(msgId common.Hash, confirmations uint64, t
 
 		msg, err := c.GetMsg(msgId)
 		if err != nil {
-			time.Sleep(1 * time.Second)
+			time.Sleep(msgPollInterval)
 			continue
 		}
 
@@ -217,7 +318,7 @@ func (c SimpleManager) WaitMsgReceipt(msgId common.Hash, confirmations uint64, t
 		}
 
 		if msg.Receipt == nil {
-			time.Sleep(1 * time.Second)
+			time.Sleep(msgPollInterval)
 			continue
 		}
 
@@ -241,6 +342,28 @@ func (c SimpleManager) WaitMsgReceipt(msgId common.Hash, confirmations uint64, t
 	}
 }
 
+func (c SimpleManager) WaitMsgFinalized(msgId common.Hash, timeout time.Duration) (*Receipt, bool) {
+	startTime := time.Now()
+	for time.Since(startTime) < timeout {
+		msg, err := c.GetMsg(msgId)
+		if err != nil {
+			time.Sleep(msgPollInterval)
+			continue
+		}
+
+		switch msg.Status {
+		case MessageStatusFinalized:
+			return msg.Receipt, true
+		case MessageStatusCancelled, MessageStatusExpired, MessageStatusSkipped, MessageStatusNonceReleased:
+			return nil, false
+		}
+
+		time.Sleep(msgPollInterval)
+	}
+
+	return nil, false
+}
+
 func (c *SimpleManager) callAndSendMsg(ctx context.Context, msg Request) (resp Response) {
 	resp = c.CallMsg(ctx, msg, nil)
 	if resp.Err != nil {
@@ -260,19 +383,50 @@ func (c *SimpleManager) callAndSendMsg(ctx context.Context, msg Request) (resp R
 
 func (m SimpleManager) sendMsg(ctx context.Context, msg Request) (signedTx *types.Transaction, err error) {
 	log.Debug("broadcast msg", "msg", msg)
+	if msg.Value != nil {
+		// gas estimation fails as well if the value could not be covered
+		err = m.checkFunds(ctx, msg.Id(), msg.From, msg.Value)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	tx, err := m.NewTransaction(ctx, msg)
 	if err != nil {
 		return nil, fmt.Errorf("NewTransaction err: %v", err)
 	}
 
+	err = m.checkFunds(ctx, msg.Id(), msg.From, tx.Cost())
+	if err != nil {
+		// the nonce was never used, so that it could be allocated once the account was funded
+		releaseErr := m.nm.ReleaseNonce(ctx, msg.From, tx.Nonce())
+		if releaseErr != nil {
+			log.Error("release nonce failed", "msgId", msg.Id(), "nonce", tx.Nonce(), "err", releaseErr)
+		}
+		return nil, err
+	}
+
 	err = m.UpdateMsgStatus(msg.Id(), MessageStatusNonceAssigned)
 	if err != nil {
 		return nil, err
 	}
+	m.inFlights.track(msg.From, msg.Id(), tx.Cost())
 
 	signedTx, err = m.signMsgAndBroadcast(ctx, msg.Id(), msg.From, tx)
 
 	if err != nil {
+		// the nonce was allocated but never used, otherwise later msgs stall
+		releaseErr := m.nm.ReleaseNonce(ctx, msg.From, tx.Nonce())
+		if releaseErr != nil {
+			log.Error("release nonce failed", "msgId", msg.Id(), "nonce", tx.Nonce(), "err", releaseErr)
+		}
+
+		// funds reserved by the msg are given back
+		m.inFlights.untrack(msg.From, msg.Id())
+		releaseErr = m.TransitMsgStatus(msg.Id(), []MessageStatus{MessageStatusNonceAssigned}, MessageStatusNonceReleased)
+		if releaseErr != nil {
+			log.Error("transit status of msg failed", "msgId", msg.Id(), "err", releaseErr)
+		}
 		return nil, err
 	}
 
@@ -305,9 +459,23 @@ func (m SimpleManager) replaceMsgWithHigherGasPrice(ctx context.Context, msgId c
 	if err != nil {
 		return nil, err
 	}
+	m.inFlights.track(msg.Req.From, msg.Id(), tx.Cost())
 
 	signedTx, err = m.signMsgAndBroadcast(ctx, msg.Id(), msg.Req.From, tx)
 
+	if err != nil {
+		// the replaced tx is still in flight
+		m.inFlights.track(msg.Req.From, msg.Id(), msg.Resp.Tx.Cost())
+		return nil, err
+	}
+
+	// keep track of the replacement, so it can be protected and reconciled later
+	replacedMsg, err := m.GetMsg(msgId)
+	if err != nil {
+		return nil, err
+	}
+	replacedMsg.Resp.Tx = signedTx
+	err = m.UpdateMsg(replacedMsg)
 	if err != nil {
 		return nil, err
 	}
@@ -318,6 +486,72 @@ func (m SimpleManager) replaceMsgWithHigherGasPrice(ctx context.Context, msgId c
 	return signedTx, nil
 }
 
+func (m SimpleManager) cancelMsg(ctx context.Context, msgId common.Hash) (signedTx *types.Transaction, err error) {
+	log.Debug("cancel msg with zero-value self-transfer", "msgId", msgId)
+	msg, err := m.GetMsg(msgId)
+	if err != nil {
+		return nil, err
+	}
+
+	if msg.Resp == nil || msg.Resp.Tx == nil {
+		return nil, fmt.Errorf("no nonce assigned")
+	}
+
+	return m.replaceWithCancellation(ctx, msg, msg.Resp.Tx)
+}
+
+// replaceWithCancellation sends a zero-value self-transfer at the nonce of the replaced tx,
+// which is either the tx of the msg or a former cancellation stuck in the mempool.
+func (m SimpleManager) replaceWithCancellation(ctx context.Context, msg Message, replaced *types.Transaction) (signedTx *types.Transaction, err error) {
+	suggestedGasPrice, err := m.nm.SuggestGasPrice(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var tx *types.Transaction
+	if replaced.Type() == types.DynamicFeeTxType {
+		// Replacement requires at least 10% bump of both caps
+		tipCap := bumpGasPrice(replaced.GasTipCap())
+		feeCap := bumpGasPrice(replaced.GasFeeCap())
+		if suggestedGasPrice.Cmp(feeCap) > 0 {
+			feeCap = suggestedGasPrice
+		}
+
+		tx = types.NewTx(&types.DynamicFeeTx{
+			ChainID:   replaced.ChainId(),
+			Nonce:     replaced.Nonce(),
+			GasTipCap: tipCap,
+			GasFeeCap: feeCap,
+			Gas:       21000,
+			To:        &msg.Req.From,
+			Value:     big.NewInt(0),
+		})
+	} else {
+		// Replacement requires at least 10% fee bump, so do the same as replaceMsgWithHigherGasPrice
+		gasPrice := bumpGasPrice(replaced.GasPrice())
+		if suggestedGasPrice.Cmp(gasPrice) > 0 {
+			gasPrice = suggestedGasPrice
+		}
+
+		tx = types.NewTransaction(replaced.Nonce(), msg.Req.From, big.NewInt(0), 21000, gasPrice, nil)
+	}
+
+	signedTx, err = m.signMsgAndBroadcast(ctx, msg.Id(), msg.Req.From, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Info("Cancel Message successfully", "msgId", msg.Id(), "txHash", signedTx.Hash().Hex(),
+		"replacedTxHash", replaced.Hash().Hex(), "from", msg.Req.From.Hex(), "nonce", signedTx.Nonce())
+
+	return signedTx, nil
+}
+
+func bumpGasPrice(gasPrice *big.Int) *big.Int {
+	bumped := big.NewInt(0).Mul(gasPrice, big.NewInt(12))
+	return bumped.Div(bumped, big.NewInt(10))
+}
+
 func (m SimpleManager) signMsgAndBroadcast(ctx context.Context, msgId common.Hash, from common.Address, tx *types.Transaction) (signedTx *types.Transaction, err error) {
 	// chainID, err := c.Client.ChainID(ctx)
 	// if err != nil {
@@ -335,6 +569,17 @@ func (m SimpleManager) signMsgAndBroadcast(ctx context.Context, msgId common.Has
 		return nil, err
 	}
 
+	// the process may crash after broadcasting but before the response was saved
+	msg, err := m.GetMsg(msgId)
+	if err != nil {
+		return nil, err
+	}
+	msg.SignedTx = signedTx
+	err = m.UpdateMsg(msg)
+	if err != nil {
+		return nil, err
+	}
+
 	err = m.backend.SendTransaction(ctx, signedTx)
 	if err != nil {
 		return nil, fmt.Errorf("SendTransaction err: %v", err)
@@ -365,7 +610,8 @@ func (c SimpleManager) newTransactionWithNonce(ctx context.Context, msg Request,
 			AccessList: msg.AccessList,
 		}
 
-		gas, err := c.nm.EstimateGas(ctx, ethMesg)
+		// the estimator has already added a margin
+		gas, err := c.gasEstimator.EstimateGas(ctx, ethMesg)
 		if err != nil {
 			if msg.GasOnEstimationFailed == nil {
 				return nil, err
@@ -373,8 +619,7 @@ func (c SimpleManager) newTransactionWithNonce(ctx context.Context, msg Request,
 
 			msg.Gas = *msg.GasOnEstimationFailed
 		} else {
-			// Multiplier 1.5
-			msg.Gas = gas * 1500 / 1000
+			msg.Gas = gas
 			// reach out max gas, then replace gas estimated with GasOnEstimationFailed
 			if msg.GasOnEstimationFailed != nil && msg.Gas > *msg.GasOnEstimationFailed {
 				log.Warn("reach out max gas, then replace gas estimated with GasOnEstimationFailed", "msgId",
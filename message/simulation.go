@@ -0,0 +1,25 @@
+package message
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// AccountOverride is the state of an account overridden while simulating the msg,
+// e.g. an allowance approved by a msg not mined yet.
+type AccountOverride struct {
+	Nonce     uint64                      // applied if not 0
+	Code      []byte                      // applied if not nil
+	Balance   *big.Int                    // applied if not nil
+	State     map[common.Hash]common.Hash // replaces the whole storage if not nil
+	StateDiff map[common.Hash]common.Hash // overrides the storage slots
+}
+
+// Simulation is the result of calling the msg right before it was sent.
+type Simulation struct {
+	BlockNumber *big.Int // block number or tag simulated on, nil for latest
+	ReturnData  []byte
+	Revert      string // decoded revert of the call, empty if it succeeded
+	GasUsed     uint64 // gas used by the call, only set by chain simulation
+}
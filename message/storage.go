@@ -1,9 +1,14 @@
 package message
 
 import (
+	"errors"
+
 	"github.com/ethereum/go-ethereum/common"
 )
 
+// ErrStatusConflict is returned if the status of the msg was not the one expected, e.g. changed by another process.
+var ErrStatusConflict = errors.New("status conflict")
+
 type Storage interface {
 	StorageReader
 	StorageWriter
@@ -13,14 +18,32 @@ type StorageReader interface {
 	HasMsg(msgId common.Hash) bool
 	GetMsg(msgId common.Hash) (Message, error)
 	GetNonce(msgId common.Hash) (uint64, error)
+	// ListMsgs returns msgs matched in order of creation,
+	// and the cursor for next page which is empty if no more msgs.
+	ListMsgs(filter MsgFilter) (msgs []Message, nextCursor string, err error)
+
+	// GetSeries returns ErrSeriesNotFound if the series doesn't exist.
+	GetSeries(rootId common.Hash) (Series, error)
+	// ListSeries returns all series in order of creation.
+	ListSeries() ([]Series, error)
 }
 
 type StorageWriter interface {
 	AddMsg(req Request) error
+	// UpdateMsg replaces the whole msg, prefer updates of fields for msgs shared by processes.
 	UpdateMsg(msg Message) error
+	// UpdateRequest replaces the request of the msg only, other fields updated meanwhile are kept.
+	UpdateRequest(msgId common.Hash, req Request) error
 	UpdateResponse(msgId common.Hash, resp Response) error
 	UpdateReceipt(msgId common.Hash, receipt Receipt) error
+	// UpdateReceiptAndStatus writes the receipt and the status atomically,
+	// the recorded receipt is removed if receipt is nil, e.g. the tx was dropped by a reorg.
+	UpdateReceiptAndStatus(msgId common.Hash, receipt *Receipt, status MessageStatus) error
 	UpdateMsgStatus(msgId common.Hash, status MessageStatus) error
+	// TransitMsgStatus updates the status atomically if it's one of from, ErrStatusConflict otherwise.
+	TransitMsgStatus(msgId common.Hash, from []MessageStatus, to MessageStatus) error
+	// UpdateSeries adds the series if not exists.
+	UpdateSeries(series Series) error
 
 	// MsgInflightQueue() queue.Queue
 	// MsgOnChainQueue() queue.Queue
@@ -0,0 +1,155 @@
+package message
+
+import (
+	"errors"
+	"math/big"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb/memorydb"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Storage_Transitions(t *testing.T) {
+	for name, storage := range testStorages(t) {
+		t.Run(name, func(t *testing.T) {
+			testStorageTransitions(t, storage)
+		})
+	}
+}
+
+// testStorages returns storages to be tested, the redis one runs on an in-process server.
+func testStorages(t *testing.T) map[string]Storage {
+	chainId := big.NewInt(1337)
+
+	memory, err := NewMemoryStorage()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return map[string]Storage{
+		"memory": memory,
+		"db":     NewDBStorage(chainId, memorydb.New()),
+		"redis":  NewRedisStorage(chainId, newTestRedisPool(t)),
+	}
+}
+
+func testStorageTransitions(t *testing.T, storage Storage) {
+	to := common.HexToAddress("0x1")
+	req := AssignMessageId(&Request{From: common.HexToAddress("0x2"), To: &to})
+
+	err := storage.AddMsg(*req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = storage.TransitMsgStatus(req.Id(), []MessageStatus{MessageStatusScheduled}, MessageStatusQueued)
+	assert.True(t, errors.Is(err, ErrStatusConflict))
+
+	err = storage.TransitMsgStatus(req.Id(), []MessageStatus{MessageStatusSubmitted, MessageStatusScheduled}, MessageStatusQueued)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// only one of concurrent transitions wins
+	var won atomic.Int32
+	wg := sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := storage.TransitMsgStatus(req.Id(), []MessageStatus{MessageStatusQueued}, MessageStatusNonceAssigned)
+			if err == nil {
+				won.Add(1)
+			} else {
+				assert.True(t, errors.Is(err, ErrStatusConflict))
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), won.Load())
+
+	err = storage.UpdateResponse(req.Id(), Response{Id: req.Id()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the request is updated without touching other fields
+	updated := req.CopyWithoutId()
+	updated.From = common.HexToAddress("0x3")
+	err = storage.UpdateRequest(req.Id(), *updated)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msg, err := storage.GetMsg(req.Id())
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, req.Id(), msg.Id())
+	assert.Equal(t, updated.From, msg.Req.From)
+	assert.Equal(t, MessageStatusNonceAssigned, msg.Status)
+	assert.NotNil(t, msg.Resp)
+
+	// the receipt and the status land together
+	receipt := &Receipt{Id: req.Id(), TxReceipt: &types.Receipt{BlockNumber: big.NewInt(1), Logs: []*types.Log{}}}
+	err = storage.UpdateReceiptAndStatus(req.Id(), receipt, MessageStatusOnChain)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msg, err = storage.GetMsg(req.Id())
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, MessageStatusOnChain, msg.Status)
+	assert.NotNil(t, msg.Receipt)
+
+	// e.g. dropped by a reorg
+	err = storage.UpdateReceiptAndStatus(req.Id(), nil, MessageStatusInflight)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msg, err = storage.GetMsg(req.Id())
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, MessageStatusInflight, msg.Status)
+	assert.Nil(t, msg.Receipt)
+	assert.NotNil(t, msg.Resp)
+
+	err = storage.TransitMsgStatus(common.HexToHash("0x4"), []MessageStatus{MessageStatusQueued}, MessageStatusNonceAssigned)
+	assert.NotNil(t, err)
+}
+
+func Test_Storage_GuardPredicate(t *testing.T) {
+	for name, storage := range testStorages(t) {
+		t.Run(name, func(t *testing.T) {
+			req := AssignMessageId(&Request{Guard: &Guard{Predicate: func(result []byte) bool { return true }}})
+
+			err := storage.AddMsg(*req)
+			if name == "memory" {
+				assert.Nil(t, err)
+				return
+			}
+
+			// the predicate would be lost once the msg was loaded
+			assert.True(t, errors.Is(err, ErrGuardPredicateNotPersisted))
+			assert.False(t, storage.HasMsg(req.Id()))
+
+			plain := AssignMessageId(&Request{})
+			err = storage.AddMsg(*plain)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			plain.Guard = req.Guard
+			err = storage.UpdateRequest(plain.Id(), *plain)
+			assert.True(t, errors.Is(err, ErrGuardPredicateNotPersisted))
+		})
+	}
+}
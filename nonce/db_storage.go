@@ -0,0 +1,89 @@
+package nonce
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethdb"
+)
+
+var _ Storage = &DBStorage{}
+
+// DBStorage keeps nonces in an embedded key-value database (e.g. pebble or leveldb).
+// Locks are only held in the process, so the database must not be shared by multiple processes.
+type DBStorage struct {
+	chainId *big.Int
+	db      ethdb.KeyValueStore
+	lockMap sync.Map
+}
+
+func NewDBStorage(chainId *big.Int, db ethdb.KeyValueStore) *DBStorage {
+	return &DBStorage{
+		chainId: chainId,
+		db:      db,
+	}
+}
+
+func (s *DBStorage) NonceLockFrom(from common.Address) sync.Locker {
+	lock, _ := s.lockMap.LoadOrStore(from, &sync.Mutex{})
+	return lock.(*sync.Mutex)
+}
+
+func (s *DBStorage) GetNonce(account common.Address) (uint64, error) {
+	nonceKey := []byte(fmt.Sprintf("nonce-chain-%s-account-%s", s.chainId.String(), strings.ToLower(account.Hex())))
+	has, err := s.db.Has(nonceKey)
+	if err != nil || !has {
+		return 0, err
+	}
+
+	nonceBytes, err := s.db.Get(nonceKey)
+	if err != nil {
+		return 0, err
+	}
+
+	return strconv.ParseUint(string(nonceBytes), 10, 64)
+}
+
+func (s *DBStorage) SetNonce(account common.Address, nonce uint64) error {
+	nonceKey := []byte(fmt.Sprintf("nonce-chain-%s-account-%s", s.chainId.String(), strings.ToLower(account.Hex())))
+
+	return s.db.Put(nonceKey, []byte(strconv.FormatUint(nonce, 10)))
+}
+
+func (s *DBStorage) GetReleasedNonces(account common.Address) ([]uint64, error) {
+	releasedKey := []byte(fmt.Sprintf("released-nonces-chain-%s-account-%s", s.chainId.String(), strings.ToLower(account.Hex())))
+
+	nonces := []uint64{}
+	has, err := s.db.Has(releasedKey)
+	if err != nil || !has {
+		return nonces, err
+	}
+
+	releasedBytes, err := s.db.Get(releasedKey)
+	if err != nil {
+		return nil, err
+	}
+
+	err = json.Unmarshal(releasedBytes, &nonces)
+	if err != nil {
+		return nil, err
+	}
+
+	return nonces, nil
+}
+
+func (s *DBStorage) SetReleasedNonces(account common.Address, nonces []uint64) error {
+	releasedKey := []byte(fmt.Sprintf("released-nonces-chain-%s-account-%s", s.chainId.String(), strings.ToLower(account.Hex())))
+
+	releasedBytes, err := json.Marshal(nonces)
+	if err != nil {
+		return err
+	}
+
+	return s.db.Put(releasedKey, releasedBytes)
+}
@@ -0,0 +1,77 @@
+package nonce
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethdb/pebble"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_DBStorage_Restart(t *testing.T) {
+	dir := t.TempDir()
+	chainId := big.NewInt(1337)
+	account := common.HexToAddress("0x1")
+
+	db, err := pebble.New(dir, 16, 16, "", false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	storage := NewDBStorage(chainId, db)
+
+	nonce, err := storage.GetNonce(account)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, uint64(0), nonce)
+
+	released, err := storage.GetReleasedNonces(account)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Empty(t, released)
+
+	err = storage.SetNonce(account, 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = storage.SetReleasedNonces(account, []uint64{3, 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = db.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	db, err = pebble.New(dir, 16, 16, "", false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	storage = NewDBStorage(chainId, db)
+
+	nonce, err = storage.GetNonce(account)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, uint64(7), nonce)
+
+	released, err = storage.GetReleasedNonces(account)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []uint64{3, 5}, released)
+
+	// nonces are scoped by chain id
+	nonce, err = NewDBStorage(big.NewInt(1), db).GetNonce(account)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, uint64(0), nonce)
+}
@@ -0,0 +1,119 @@
+package nonce
+
+import (
+	"context"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ivanzzeth/ethclient/common/consts"
+)
+
+// GapChecker fills nonce gaps which can not be recovered with no-op self-transfers,
+// otherwise every later transaction from the account stalls.
+type GapChecker struct {
+	backend  gapCheckerBackend
+	nm       Manager
+	signerFn bind.SignerFn
+	interval time.Duration
+	accounts sync.Map
+
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func NewGapChecker(backend gapCheckerBackend, nm Manager, signerFn bind.SignerFn) *GapChecker {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &GapChecker{
+		backend:  backend,
+		nm:       nm,
+		signerFn: signerFn,
+		interval: consts.DefaultNonceGapCheckInterval,
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+}
+
+func (c *GapChecker) SetInterval(interval time.Duration) {
+	c.interval = interval
+}
+
+func (c *GapChecker) SetNonceManager(nm Manager) {
+	c.nm = nm
+}
+
+// Watch adds the account into the checklist.
+func (c *GapChecker) Watch(account common.Address) {
+	c.accounts.Store(account, struct{}{})
+}
+
+// Run checks all watched accounts periodically until Close was called.
+func (c *GapChecker) Run() {
+	for {
+		select {
+		case <-c.ctx.Done():
+			return
+		case <-time.After(c.interval):
+			c.accounts.Range(func(key, _ any) bool {
+				account := key.(common.Address)
+				err := c.Check(c.ctx, account)
+				if err != nil {
+					log.Error("check nonce gaps failed", "account", account.Hex(), "err", err)
+				}
+				return true
+			})
+		}
+	}
+}
+
+func (c *GapChecker) Close() {
+	c.cancel()
+}
+
+// Check fills all gaps of the account claimed from the nonce manager.
+func (c *GapChecker) Check(ctx context.Context, account common.Address) error {
+	for {
+		nonce, found, err := c.nm.ClaimNonceGap(ctx, account)
+		if err != nil {
+			return err
+		}
+
+		if !found {
+			return nil
+		}
+
+		err = c.fill(ctx, account, nonce)
+		if err != nil {
+			// give it back, so it will be claimed again on next check
+			c.nm.ReleaseNonce(ctx, account, nonce)
+			return err
+		}
+	}
+}
+
+func (c *GapChecker) fill(ctx context.Context, account common.Address, nonce uint64) error {
+	gasPrice, err := c.nm.SuggestGasPrice(ctx)
+	if err != nil {
+		return err
+	}
+
+	tx := types.NewTransaction(nonce, account, big.NewInt(0), 21000, gasPrice, nil)
+	signedTx, err := c.signerFn(account, tx)
+	if err != nil {
+		return err
+	}
+
+	err = c.backend.SendTransaction(ctx, signedTx)
+	if err != nil {
+		return err
+	}
+
+	log.Warn("filled nonce gap with no-op self-transfer", "account", account.Hex(), "nonce", nonce, "txHash", signedTx.Hash().Hex())
+
+	return nil
+}
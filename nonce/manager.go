@@ -15,10 +15,21 @@ type ethBackend interface {
 	ethereum.GasEstimator
 }
 
+type gapCheckerBackend interface {
+	ethBackend
+	ethereum.TransactionSender
+}
+
 type Manager interface {
 	PendingNonceAt(ctx context.Context, account common.Address) (uint64, error)
 	PeekNonce(account common.Address) (uint64, error)
 	ResetNonce(ctx context.Context, account common.Address) error
+	// ReleaseNonce gives back the nonce allocated by PendingNonceAt but never used,
+	// so that it could be allocated again.
+	ReleaseNonce(ctx context.Context, account common.Address, nonce uint64) error
+	// ClaimNonceGap returns the nonce which stalls later transactions of the account,
+	// the caller must fill it.
+	ClaimNonceGap(ctx context.Context, account common.Address) (nonce uint64, found bool, err error)
 	EstimateGas(ctx context.Context, msg ethereum.CallMsg) (uint64, error)
 	SuggestGasPrice(ctx context.Context) (*big.Int, error)
 	SetNonceAt(nonceAt NonceAtFunc)
@@ -9,14 +9,17 @@ import (
 var _ Storage = &MemoryStorage{}
 
 type MemoryStorage struct {
-	lockMap  sync.Map
-	nonceMap map[common.Address]uint64
+	lockMap     sync.Map
+	lock        sync.RWMutex // guards the maps, which are accessed by different accounts concurrently
+	nonceMap    map[common.Address]uint64
+	releasedMap map[common.Address][]uint64
 }
 
 func NewMemoryStorage() *MemoryStorage {
 	return &MemoryStorage{
-		nonceMap: make(map[common.Address]uint64),
-		lockMap:  sync.Map{},
+		nonceMap:    make(map[common.Address]uint64),
+		releasedMap: make(map[common.Address][]uint64),
+		lockMap:     sync.Map{},
 	}
 }
 
@@ -26,13 +29,37 @@ func (s *MemoryStorage) NonceLockFrom(from common.Address) sync.Locker {
 }
 
 func (s *MemoryStorage) GetNonce(account common.Address) (uint64, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
 	nonce := s.nonceMap[account]
 
 	return nonce, nil
 }
 
 func (s *MemoryStorage) SetNonce(account common.Address, nonce uint64) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
 	s.nonceMap[account] = nonce
 
 	return nil
 }
+
+func (s *MemoryStorage) GetReleasedNonces(account common.Address) ([]uint64, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	nonces := s.releasedMap[account]
+
+	return append([]uint64{}, nonces...), nil
+}
+
+func (s *MemoryStorage) SetReleasedNonces(account common.Address, nonces []uint64) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.releasedMap[account] = append([]uint64{}, nonces...)
+
+	return nil
+}
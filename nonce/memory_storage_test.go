@@ -0,0 +1,42 @@
+package nonce
+
+import (
+	"math/big"
+	"sync"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_MemoryStorage_ConcurrentAccounts(t *testing.T) {
+	storage := NewMemoryStorage()
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			// locks of different accounts don't exclude each other
+			account := common.BigToAddress(big.NewInt(int64(i + 1)))
+			locker := storage.NonceLockFrom(account)
+			locker.Lock()
+			defer locker.Unlock()
+
+			for n := uint64(1); n <= 100; n++ {
+				assert.Nil(t, storage.SetNonce(account, n))
+				assert.Nil(t, storage.SetReleasedNonces(account, []uint64{n}))
+			}
+
+			nonce, err := storage.GetNonce(account)
+			assert.Nil(t, err)
+			assert.Equal(t, uint64(100), nonce)
+
+			released, err := storage.GetReleasedNonces(account)
+			assert.Nil(t, err)
+			assert.Equal(t, []uint64{100}, released)
+		}()
+	}
+	wg.Wait()
+}
@@ -2,6 +2,7 @@ package nonce
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"math/big"
 	"strconv"
@@ -82,3 +83,53 @@ func (s *RedisStorage) SetNonce(account common.Address, nonce uint64) error {
 
 	return nil
 }
+
+func (s *RedisStorage) GetReleasedNonces(account common.Address) ([]uint64, error) {
+	conn, err := s.redisPool.Get(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	releasedKey := fmt.Sprintf("released-nonces-chain-%s-account-%s", s.chainId.String(), strings.ToLower(account.Hex()))
+	releasedStr, err := conn.Get(releasedKey)
+	if err != nil {
+		return nil, err
+	}
+
+	nonces := []uint64{}
+	if releasedStr == "" {
+		return nonces, nil
+	}
+
+	err = json.Unmarshal([]byte(releasedStr), &nonces)
+	if err != nil {
+		return nil, err
+	}
+
+	return nonces, nil
+}
+
+func (s *RedisStorage) SetReleasedNonces(account common.Address, nonces []uint64) error {
+	conn, err := s.redisPool.Get(context.Background())
+	if err != nil {
+		return err
+	}
+
+	releasedKey := fmt.Sprintf("released-nonces-chain-%s-account-%s", s.chainId.String(), strings.ToLower(account.Hex()))
+
+	releasedBytes, err := json.Marshal(nonces)
+	if err != nil {
+		return err
+	}
+
+	ok, err := conn.Set(releasedKey, string(releasedBytes))
+	if err != nil {
+		return err
+	}
+
+	if !ok {
+		return fmt.Errorf("set released nonces failed")
+	}
+
+	return nil
+}
@@ -3,6 +3,7 @@ package nonce
 import (
 	"context"
 	"math/big"
+	"slices"
 	"sync"
 
 	"github.com/ethereum/go-ethereum"
@@ -16,6 +17,7 @@ type SimpleManager struct {
 	Storage
 	backend ethBackend
 	NonceAt NonceAtFunc
+	gaps    sync.Map // account -> pending nonce seen as a gap on last check
 }
 
 var snm *SimpleManager
@@ -55,23 +57,36 @@ func (nm *SimpleManager) PendingNonceAt(ctx context.Context, account common.Addr
 		return 0, err
 	}
 
-	var nonceInLatest uint64
-	if nm.NonceAt == nil {
-		nonceInLatest, err = nm.backend.NonceAt(ctx, account, nil)
-		if err != nil {
-			return 0, err
-		}
-	} else {
-		nonceInLatest, err = nm.NonceAt(ctx, account, nil)
-		if err != nil {
-			return 0, err
-		}
+	nonceInLatest, err := nm.nonceAt(ctx, account)
+	if err != nil {
+		return 0, err
 	}
 
 	if nonce == 0 || nonceInLatest > nonce {
 		nonce = nonceInLatest
 	}
 
+	released, err := nm.GetReleasedNonces(account)
+	if err != nil {
+		return 0, err
+	}
+
+	// drop released nonces which were used by others
+	released = slices.DeleteFunc(released, func(n uint64) bool {
+		return n < nonceInLatest || n >= nonce
+	})
+
+	if len(released) > 0 {
+		reused := released[0]
+		err = nm.SetReleasedNonces(account, released[1:])
+		if err != nil {
+			return 0, err
+		}
+
+		log.Info("pending nonce at reuses released nonce", "account", account.Hex(), "nonce", reused, "nonceInLatest", nonceInLatest)
+		return reused, nil
+	}
+
 	log.Info("pending nonce at", "account", account.Hex(), "nonce", nonce, "nonceInLatest", nonceInLatest)
 
 	err = nm.SetNonce(account, nonce+1)
@@ -124,17 +139,9 @@ func (nm *SimpleManager) ResetNonce(ctx context.Context, account common.Address)
 	locker.Lock()
 	defer locker.Unlock()
 
-	var nonceInLatest uint64
-	if nm.NonceAt == nil {
-		nonceInLatest, err = nm.backend.NonceAt(ctx, account, nil)
-		if err != nil {
-			return err
-		}
-	} else {
-		nonceInLatest, err = nm.NonceAt(ctx, account, nil)
-		if err != nil {
-			return err
-		}
+	nonceInLatest, err := nm.nonceAt(ctx, account)
+	if err != nil {
+		return err
 	}
 
 	err = nm.SetNonce(account, nonceInLatest)
@@ -142,9 +149,111 @@ func (nm *SimpleManager) ResetNonce(ctx context.Context, account common.Address)
 		return err
 	}
 
-	return nil
+	return nm.SetReleasedNonces(account, []uint64{})
+}
+
+func (nm *SimpleManager) ReleaseNonce(ctx context.Context, account common.Address, nonce uint64) error {
+	locker := nm.NonceLockFrom(account)
+	locker.Lock()
+	defer locker.Unlock()
+
+	next, err := nm.GetNonce(account)
+	if err != nil {
+		return err
+	}
+
+	nonceInLatest, err := nm.nonceAt(ctx, account)
+	if err != nil {
+		return err
+	}
+
+	if nonce < nonceInLatest || nonce >= next {
+		log.Debug("no need to release nonce", "account", account.Hex(), "nonce", nonce, "nonceInLatest", nonceInLatest, "next", next)
+		return nil
+	}
+
+	released, err := nm.GetReleasedNonces(account)
+	if err != nil {
+		return err
+	}
+
+	if !slices.Contains(released, nonce) {
+		released = append(released, nonce)
+		slices.Sort(released)
+	}
+
+	// roll back the next nonce if released ones are at the tail
+	for len(released) > 0 && released[len(released)-1]+1 == next {
+		next--
+		released = released[:len(released)-1]
+	}
+
+	log.Info("release nonce", "account", account.Hex(), "nonce", nonce, "next", next, "released", released)
+
+	err = nm.SetNonce(account, next)
+	if err != nil {
+		return err
+	}
+
+	return nm.SetReleasedNonces(account, released)
+}
+
+// ClaimNonceGap compares the pending nonce on chain with the storage.
+// The pending nonce is considered as a gap if it was released,
+// or it was allocated but still missing in mempool since last check.
+func (nm *SimpleManager) ClaimNonceGap(ctx context.Context, account common.Address) (nonce uint64, found bool, err error) {
+	locker := nm.NonceLockFrom(account)
+	locker.Lock()
+	defer locker.Unlock()
+
+	next, err := nm.GetNonce(account)
+	if err != nil {
+		return 0, false, err
+	}
+
+	nonceInPending, err := nm.backend.PendingNonceAt(ctx, account)
+	if err != nil {
+		return 0, false, err
+	}
+
+	if nonceInPending >= next {
+		nm.gaps.Delete(account)
+		return 0, false, nil
+	}
+
+	released, err := nm.GetReleasedNonces(account)
+	if err != nil {
+		return 0, false, err
+	}
+
+	if i := slices.Index(released, nonceInPending); i >= 0 {
+		err = nm.SetReleasedNonces(account, slices.Delete(released, i, i+1))
+		if err != nil {
+			return 0, false, err
+		}
+
+		nm.gaps.Delete(account)
+		return nonceInPending, true, nil
+	}
+
+	if last, ok := nm.gaps.Load(account); ok && last.(uint64) == nonceInPending {
+		nm.gaps.Delete(account)
+		return nonceInPending, true, nil
+	}
+
+	log.Debug("nonce gap found", "account", account.Hex(), "nonceInPending", nonceInPending, "next", next)
+	nm.gaps.Store(account, nonceInPending)
+	return 0, false, nil
 }
 
 func (nm *SimpleManager) SetNonceAt(nonceAt NonceAtFunc) {
 	nm.NonceAt = nonceAt
 }
+
+func (nm *SimpleManager) nonceAt(ctx context.Context, account common.Address) (uint64, error) {
+	if nm.NonceAt == nil {
+		return nm.backend.NonceAt(ctx, account, nil)
+	}
+
+	return nm.NonceAt(ctx, account, nil)
+}
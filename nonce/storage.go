@@ -12,4 +12,9 @@ type Storage interface {
 	GetNonce(account common.Address) (uint64, error)
 	// without locks
 	SetNonce(account common.Address, nonce uint64) error
+	// without locks
+	// Nonces were allocated but never used, sorted in ascending order.
+	GetReleasedNonces(account common.Address) ([]uint64, error)
+	// without locks
+	SetReleasedNonces(account common.Address, nonces []uint64) error
 }
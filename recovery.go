@@ -0,0 +1,188 @@
+package ethclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ivanzzeth/ethclient/message"
+)
+
+// RecoverMsgs reloads msgs matched which were not terminated before restart,
+// and brings them back to the pipeline after reconciling all of them with the chain.
+// Status of the filter is ignored, and CreatedAfter is recommended for not scanning the whole history.
+// It's opt-in and should be called before scheduling new msgs. Processes sharing a storage must scope
+// their msgs by Labels, otherwise msgs of others would be sent twice.
+func (c *Client) RecoverMsgs(ctx context.Context, filter message.MsgFilter) error {
+	filter.Status = []message.MessageStatus{
+		message.MessageStatusSubmitted,
+		message.MessageStatusScheduled,
+		message.MessageStatusQueued,
+		message.MessageStatusWaitingForFunds,
+		message.MessageStatusNonceAssigned,
+		message.MessageStatusInflight,
+		message.MessageStatusOnChain,
+	}
+	filter.Cursor = ""
+
+	// collect all of them first, recovered msgs may create new msgs.
+	msgs := []message.Message{}
+	for {
+		page, next, err := c.msgStore.ListMsgs(filter)
+		if err != nil {
+			return err
+		}
+
+		msgs = append(msgs, page...)
+
+		if next == "" {
+			break
+		}
+		filter.Cursor = next
+	}
+
+	if len(msgs) > 0 {
+		log.Info("recover msgs", "count", len(msgs))
+	}
+
+	// nothing is resumed until all msgs were reconciled, so that it could be retried on failure.
+	resumes := []func(){}
+	for _, msg := range msgs {
+		resume, err := c.recoverMsg(ctx, msg)
+		if err != nil {
+			return fmt.Errorf("recover msg %v failed: %v", msg.Id().Hex(), err)
+		}
+
+		if resume != nil {
+			resumes = append(resumes, resume)
+		}
+	}
+
+	// msgs are in order of creation, so that AfterMsg dependencies are mostly pushed after their predecessors.
+	for _, resume := range resumes {
+		resume()
+	}
+
+	return nil
+}
+
+// recoverMsg reconciles the msg, and returns how to bring it back to the pipeline if needed.
+func (c *Client) recoverMsg(ctx context.Context, msg message.Message) (resume func(), err error) {
+	log.Debug("recover msg", "msgId", msg.Id().Hex(), "status", msg.Status)
+
+	switch msg.Status {
+	case message.MessageStatusSubmitted:
+		return func() { c.reqChannel <- *msg.Req }, nil
+	case message.MessageStatusScheduled, message.MessageStatusQueued, message.MessageStatusWaitingForFunds:
+		if msg.Resp != nil {
+			return nil, nil
+		}
+
+		// already scheduled, so skip scheduler for not creating recurring msgs twice
+		return func() { c.scheduleChannel <- *msg.Req }, nil
+	case message.MessageStatusNonceAssigned, message.MessageStatusInflight:
+		return c.reconcileInflightMsg(ctx, msg)
+	case message.MessageStatusOnChain:
+		// may be reorged while the client was down
+		return func() { c.broadcaster.WatchMsg(ctx, msg.Id()) }, nil
+	}
+
+	return nil, nil
+}
+
+func (c *Client) reconcileInflightMsg(ctx context.Context, msg message.Message) (resume func(), err error) {
+	if msg.Resp == nil && msg.SignedTx == nil {
+		// never signed, so it was not broadcasted and it's safe to send again.
+		// the nonce gap will be filled if it was allocated.
+		log.Warn("msg was interrupted before broadcasting", "msgId", msg.Id().Hex())
+
+		err = c.msgStore.TransitMsgStatus(msg.Id(), []message.MessageStatus{msg.Status}, message.MessageStatusNonceReleased)
+		if err != nil {
+			return nil, err
+		}
+
+		resp := message.Response{Id: msg.Id(), Err: message.ErrMsgInterrupted}
+		err = c.msgStore.UpdateResponse(msg.Id(), resp)
+		if err != nil {
+			return nil, err
+		}
+
+		return func() { c.gapChecker.Watch(msg.Req.From) }, nil
+	}
+
+	if msg.Resp == nil {
+		// the signed tx may or may not be broadcasted, so it's reconciled as broadcasted,
+		// and broadcasted again below if it's not in the mempool. It's never signed again.
+		log.Warn("msg was interrupted while broadcasting", "msgId", msg.Id().Hex(), "txHash", msg.SignedTx.Hash().Hex())
+
+		resp := message.Response{Id: msg.Id(), Tx: msg.SignedTx}
+		err = c.msgStore.UpdateResponse(msg.Id(), resp)
+		if err != nil {
+			return nil, err
+		}
+		msg.Resp = &resp
+
+		err = c.msgStore.TransitMsgStatus(msg.Id(), []message.MessageStatus{msg.Status}, message.MessageStatusInflight)
+		if err != nil {
+			return nil, err
+		}
+		msg.Status = message.MessageStatusInflight
+	}
+
+	if msg.Resp.Err != nil {
+		// failed to be broadcasted, and its nonce was released then
+		return nil, c.msgStore.TransitMsgStatus(msg.Id(), []message.MessageStatus{msg.Status}, message.MessageStatusNonceReleased)
+	}
+
+	if msg.Resp.Tx == nil {
+		return nil, nil
+	}
+
+	tx := msg.Resp.Tx
+	receipt, err := c.TransactionReceipt(ctx, tx.Hash())
+	if err == nil {
+		err = c.msgStore.UpdateReceiptAndStatus(msg.Id(), &message.Receipt{Id: msg.Id(), TxReceipt: receipt}, message.MessageStatusOnChain)
+		if err != nil {
+			return nil, err
+		}
+
+		return func() { c.broadcaster.WatchMsg(ctx, msg.Id()) }, nil
+	}
+
+	if !errors.Is(err, ethereum.NotFound) {
+		return nil, err
+	}
+
+	nonceInLatest, err := c.Client.NonceAt(ctx, msg.Req.From, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if nonceInLatest > tx.Nonce() {
+		log.Warn("nonce of msg was used by another tx", "msgId", msg.Id().Hex(), "nonce", tx.Nonce())
+		return nil, c.msgStore.TransitMsgStatus(msg.Id(), []message.MessageStatus{msg.Status}, message.MessageStatusNonceReleased)
+	}
+
+	pendingNonce, err := c.Client.PendingNonceAt(ctx, msg.Req.From)
+	if err != nil {
+		return nil, err
+	}
+
+	if pendingNonce <= tx.Nonce() {
+		// never reached the mempool or dropped from it, the same tx is sent, so that it's never paid twice
+		log.Info("broadcast tx of recovered msg again", "msgId", msg.Id().Hex(), "txHash", tx.Hash().Hex())
+		err = c.Client.SendTransaction(ctx, tx)
+		if err != nil {
+			// it will be replaced by protection if it's stuck
+			log.Warn("broadcast tx of recovered msg failed", "msgId", msg.Id().Hex(), "err", err)
+		}
+	}
+
+	// protect it as usual
+	return func() {
+		c.gapChecker.Watch(msg.Req.From)
+		c.broadcaster.ProtectMsg(ctx, msg.Id())
+	}, nil
+}
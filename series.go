@@ -0,0 +1,143 @@
+package ethclient
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ivanzzeth/ethclient/message"
+)
+
+func (c *Client) GetSeries(rootId common.Hash) (message.Series, error) {
+	return c.msgStore.GetSeries(rootId)
+}
+
+// ListSeries returns all recurring tasks scheduled by ScheduleMsg.
+func (c *Client) ListSeries() ([]message.Series, error) {
+	return c.msgStore.ListSeries()
+}
+
+// PauseSeries stops creating msgs of the recurring task until ResumeSeries,
+// the pending msg of the task waits for resuming as well.
+func (c *Client) PauseSeries(rootId common.Hash) error {
+	return c.setSeriesStatus(rootId, message.SeriesStatusActive, message.SeriesStatusPaused)
+}
+
+// ResumeSeries continues the paused recurring task from now on, runs missed during the pause are not caught up.
+func (c *Client) ResumeSeries(rootId common.Hash) error {
+	return c.setSeriesStatus(rootId, message.SeriesStatusPaused, message.SeriesStatusActive)
+}
+
+// StopSeries ends the recurring task permanently and cancels its pending msgs.
+func (c *Client) StopSeries(rootId common.Hash) error {
+	series, err := c.msgStore.GetSeries(rootId)
+	if err != nil {
+		return err
+	}
+
+	if series.Status != message.SeriesStatusStopped {
+		series.Status = message.SeriesStatusStopped
+		series.UpdatedAt = time.Now().UnixNano()
+		err = c.msgStore.UpdateSeries(series)
+		if err != nil {
+			return err
+		}
+	}
+
+	pending := []message.MessageStatus{message.MessageStatusSubmitted, message.MessageStatusScheduled, message.MessageStatusQueued}
+
+	ids := []common.Hash{rootId}
+	filter := message.MsgFilter{Root: &rootId, Status: pending}
+	for {
+		msgs, cursor, err := c.msgStore.ListMsgs(filter)
+		if err != nil {
+			return err
+		}
+
+		for _, msg := range msgs {
+			ids = append(ids, msg.Id())
+		}
+
+		if cursor == "" {
+			break
+		}
+		filter.Cursor = cursor
+	}
+
+	for _, id := range ids {
+		msg, err := c.msgStore.GetMsg(id)
+		if err != nil || !slices.Contains(pending, msg.Status) {
+			continue
+		}
+
+		err = c.CancelMsg(id)
+		if err != nil {
+			log.Warn("cancel msg of stopped series failed", "rootId", rootId.Hex(), "msgId", id.Hex(), "err", err)
+		}
+	}
+
+	return nil
+}
+
+func (c *Client) setSeriesStatus(rootId common.Hash, from, to message.SeriesStatus) error {
+	series, err := c.msgStore.GetSeries(rootId)
+	if err != nil {
+		return err
+	}
+
+	if series.Status == to {
+		return nil
+	}
+
+	if series.Status != from {
+		return fmt.Errorf("series %v is %v", rootId.Hex(), series.Status)
+	}
+
+	series.Status = to
+	series.UpdatedAt = time.Now().UnixNano()
+	return c.msgStore.UpdateSeries(series)
+}
+
+// seriesStatus registers the series of recurring msg if not exists and returns its status,
+// the status is active for non-recurring msgs.
+func (c *Client) seriesStatus(msg message.Message) (message.SeriesStatus, error) {
+	rootId, ok := msg.SeriesRoot()
+	if !ok {
+		return message.SeriesStatusActive, nil
+	}
+
+	series, err := c.msgStore.GetSeries(rootId)
+	if err == nil {
+		return series.Status, nil
+	}
+	if !errors.Is(err, message.ErrSeriesNotFound) {
+		return 0, err
+	}
+
+	if msg.Root != nil {
+		// created before series were stored
+		return message.SeriesStatusActive, nil
+	}
+
+	return message.SeriesStatusActive, c.registerSeries(rootId)
+}
+
+// registerSeries adds the series as active if not exists,
+// so that it could be paused or stopped before its first run.
+func (c *Client) registerSeries(rootId common.Hash) error {
+	_, err := c.msgStore.GetSeries(rootId)
+	if !errors.Is(err, message.ErrSeriesNotFound) {
+		return err
+	}
+
+	now := time.Now().UnixNano()
+	return c.msgStore.UpdateSeries(message.Series{
+		RootId:    rootId,
+		Status:    message.SeriesStatusActive,
+		CreatedAt: now,
+		UpdatedAt: now,
+	})
+}
@@ -32,12 +32,16 @@ import (
 	"github.com/ethereum/go-ethereum/eth/downloader"
 	"github.com/ethereum/go-ethereum/eth/ethconfig"
 	"github.com/ethereum/go-ethereum/eth/filters"
+	"github.com/ethereum/go-ethereum/eth/tracers"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/node"
 	"github.com/ethereum/go-ethereum/p2p"
 	"github.com/ethereum/go-ethereum/params"
 	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/ivanzzeth/ethclient"
+
+	// Register the native tracers, e.g. callTracer and prestateTracer
+	_ "github.com/ethereum/go-ethereum/eth/tracers/native"
 )
 
 // Backend is a simulated blockchain. You can use it to test your contracts or
@@ -171,6 +175,8 @@ func newWithNode(stack *node.Node, conf *eth.Config, blockPeriod uint64) (*Backe
 		Namespace: "eth",
 		Service:   filters.NewFilterAPI(filterSystem),
 	}})
+	// Register the debug APIs, e.g. debug_traceCall
+	stack.RegisterAPIs(tracers.APIs(backend.APIBackend))
 	// Start the node
 	if err := stack.Start(); err != nil {
 		return nil, err
@@ -0,0 +1,376 @@
+package ethclient
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"math/big"
+	"slices"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient/gethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ivanzzeth/ethclient/common/consts"
+	"github.com/ivanzzeth/ethclient/message"
+)
+
+// simulateMsg calls the msg on the block of simulation with its state overrides,
+// err is not nil if the call failed, and the revert is decoded by ABIs added.
+func (c *Client) simulateMsg(ctx context.Context, req message.Request) (*message.Simulation, error) {
+	msg := ethereum.CallMsg{
+		From:       req.From,
+		To:         req.To,
+		Gas:        req.Gas,
+		GasPrice:   req.GasPrice,
+		Value:      req.Value,
+		Data:       req.Data,
+		AccessList: req.AccessList,
+	}
+
+	var overrides *map[common.Address]gethclient.OverrideAccount
+	if len(req.SimulationOverrides) > 0 {
+		accounts := make(map[common.Address]gethclient.OverrideAccount, len(req.SimulationOverrides))
+		for addr, o := range req.SimulationOverrides {
+			accounts[addr] = gethclient.OverrideAccount{
+				Nonce:     o.Nonce,
+				Code:      o.Code,
+				Balance:   o.Balance,
+				State:     o.State,
+				StateDiff: o.StateDiff,
+			}
+		}
+		overrides = &accounts
+	}
+
+	simulation := &message.Simulation{BlockNumber: req.SimulationBlock}
+
+	ret, err := c.CallContractWithAccountOverride(ctx, msg, req.SimulationBlock, overrides)
+	if err != nil {
+		simulation.Revert = revertOf(err)
+		return simulation, err
+	}
+
+	simulation.ReturnData = ret
+	return simulation, nil
+}
+
+// traceAccount is the account state returned by prestateTracer.
+type traceAccount struct {
+	Balance *hexutil.Big                `json:"balance"`
+	Code    *hexutil.Bytes              `json:"code"`
+	Nonce   uint64                      `json:"nonce"`
+	Storage map[common.Hash]common.Hash `json:"storage"`
+}
+
+// chainTrace is the result of muxTracer running callTracer and prestateTracer in diff mode.
+type chainTrace struct {
+	Call struct {
+		GasUsed hexutil.Uint64 `json:"gasUsed"`
+		Output  hexutil.Bytes  `json:"output"`
+		Error   string         `json:"error"`
+	} `json:"callTracer"`
+	Prestate struct {
+		Pre  map[common.Address]traceAccount `json:"pre"`
+		Post map[common.Address]traceAccount `json:"post"`
+	} `json:"prestateTracer"`
+}
+
+// SimulateChain simulates the reqs in order on a fork of the pending state (or SimulationBlock of the first req),
+// every req sees the state changes of previous ones, e.g. a chain of msgs depending on each other by AfterMsg.
+// A reverted req does not stop the simulation, its state changes are discarded like it was mined.
+//
+// debug_traceCall could not trace on top of the pending block, so the pending state is the latest block
+// with txs of msgs in flight of the client applied in order of their nonces, txs of others in the mempool are not seen.
+// Other block tags are resolved to numbers before simulation.
+//
+// The node must support debug_traceCall with callTracer and prestateTracer.
+func (c *Client) SimulateChain(ctx context.Context, reqs []message.Request) ([]message.Simulation, error) {
+	if len(reqs) == 0 {
+		return nil, nil
+	}
+
+	// all reqs are simulated on the same block even if new blocks are mined during simulation
+	blockNumber := reqs[0].SimulationBlock
+	pending := blockNumber == nil || blockNumber.Cmp(big.NewInt(int64(rpc.PendingBlockNumber))) == 0
+	switch {
+	case pending:
+		latest, err := c.BlockNumber(ctx)
+		if err != nil {
+			return nil, err
+		}
+		blockNumber = new(big.Int).SetUint64(latest)
+	case blockNumber.Sign() < 0:
+		header, err := c.HeaderByNumber(ctx, blockNumber)
+		if err != nil {
+			return nil, err
+		}
+		blockNumber = header.Number
+	}
+
+	state := make(map[common.Address]gethclient.OverrideAccount)
+	if pending {
+		inFlights, err := c.inFlightReqs(ctx, blockNumber)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, req := range inFlights {
+			trace, err := c.traceChainCall(ctx, req, blockNumber, state)
+			if err != nil {
+				return nil, fmt.Errorf("simulate msg in flight: %w", err)
+			}
+			applyStateDiff(state, trace.Prestate.Pre, trace.Prestate.Post)
+		}
+	}
+
+	simulations := make([]message.Simulation, 0, len(reqs))
+	for i, req := range reqs {
+		for addr, o := range req.SimulationOverrides {
+			state[addr] = mergeOverride(state[addr], gethclient.OverrideAccount{
+				Nonce:     o.Nonce,
+				Code:      o.Code,
+				Balance:   o.Balance,
+				State:     o.State,
+				StateDiff: o.StateDiff,
+			})
+		}
+
+		trace, err := c.traceChainCall(ctx, req, blockNumber, state)
+		if err != nil {
+			return simulations, fmt.Errorf("simulate req %d: %w", i, err)
+		}
+
+		simulation := message.Simulation{
+			BlockNumber: blockNumber,
+			ReturnData:  trace.Call.Output,
+			GasUsed:     uint64(trace.Call.GasUsed),
+		}
+		if trace.Call.Error != "" {
+			simulation.ReturnData = nil
+			simulation.Revert = revertOf(c.DecodeJsonRpcError(traceRevertError{
+				msg:  trace.Call.Error,
+				data: trace.Call.Output,
+			}))
+		}
+		simulations = append(simulations, simulation)
+
+		applyStateDiff(state, trace.Prestate.Pre, trace.Prestate.Post)
+	}
+
+	return simulations, nil
+}
+
+// inFlightReqs returns txs of msgs broadcasted but not mined at the block as reqs, in order of nonces per sender.
+func (c *Client) inFlightReqs(ctx context.Context, blockNumber *big.Int) ([]message.Request, error) {
+	filter := message.MsgFilter{Status: []message.MessageStatus{message.MessageStatusNonceAssigned, message.MessageStatusInflight}}
+
+	senders := []common.Address{}
+	txs := make(map[common.Address][]*types.Transaction)
+	for {
+		page, next, err := c.msgStore.ListMsgs(filter)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, msg := range page {
+			if msg.Resp == nil || msg.Resp.Tx == nil {
+				// not broadcasted yet
+				continue
+			}
+
+			from := msg.Req.From
+			if _, ok := txs[from]; !ok {
+				senders = append(senders, from)
+			}
+			txs[from] = append(txs[from], msg.Resp.Tx)
+		}
+
+		if next == "" {
+			break
+		}
+		filter.Cursor = next
+	}
+
+	reqs := []message.Request{}
+	for _, from := range senders {
+		// on-chain msgs may not be updated yet
+		nonce, err := c.Client.NonceAt(ctx, from, blockNumber)
+		if err != nil {
+			return nil, err
+		}
+
+		slices.SortFunc(txs[from], func(a, b *types.Transaction) int {
+			return cmp.Compare(a.Nonce(), b.Nonce())
+		})
+		for _, tx := range txs[from] {
+			if tx.Nonce() < nonce {
+				continue
+			}
+
+			reqs = append(reqs, message.Request{
+				From:       from,
+				To:         tx.To(),
+				Value:      tx.Value(),
+				Gas:        tx.Gas(),
+				Data:       tx.Data(),
+				AccessList: tx.AccessList(),
+			})
+		}
+	}
+
+	return reqs, nil
+}
+
+func (c *Client) traceChainCall(ctx context.Context, req message.Request, blockNumber *big.Int, state map[common.Address]gethclient.OverrideAccount) (*chainTrace, error) {
+	arg := map[string]interface{}{
+		"from": req.From,
+		"to":   req.To,
+	}
+	if len(req.Data) > 0 {
+		arg["input"] = hexutil.Bytes(req.Data)
+	}
+	if req.Value != nil {
+		arg["value"] = (*hexutil.Big)(req.Value)
+	}
+	if req.Gas != 0 {
+		arg["gas"] = hexutil.Uint64(req.Gas)
+	}
+	if req.GasPrice != nil {
+		arg["gasPrice"] = (*hexutil.Big)(req.GasPrice)
+	}
+	if req.AccessList != nil {
+		arg["accessList"] = req.AccessList
+	}
+
+	config := map[string]interface{}{
+		"tracer": "muxTracer",
+		"tracerConfig": map[string]interface{}{
+			"callTracer":     map[string]interface{}{},
+			"prestateTracer": map[string]interface{}{"diffMode": true},
+		},
+		"stateOverrides": state,
+	}
+
+	var trace chainTrace
+	err := c.RpcClient().CallContext(ctx, &trace, "debug_traceCall", arg, hexutil.EncodeBig(blockNumber), config)
+	if err != nil {
+		return nil, c.DecodeJsonRpcError(err)
+	}
+
+	return &trace, nil
+}
+
+// traceRevertError is the revert of traced call, decoded as json-rpc error.
+type traceRevertError struct {
+	msg  string
+	data []byte
+}
+
+func (e traceRevertError) Error() string          { return e.msg }
+func (e traceRevertError) ErrorCode() int         { return 3 }
+func (e traceRevertError) ErrorData() interface{} { return hexutil.Encode(e.data) }
+
+// mergeOverride returns the override applying o on top of base.
+func mergeOverride(base, o gethclient.OverrideAccount) gethclient.OverrideAccount {
+	if o.Nonce != 0 {
+		base.Nonce = o.Nonce
+	}
+	if o.Code != nil {
+		base.Code = o.Code
+	}
+	if o.Balance != nil {
+		base.Balance = o.Balance
+	}
+	if o.State != nil {
+		// the whole storage is replaced
+		base.State = maps.Clone(o.State)
+		base.StateDiff = nil
+	}
+
+	if len(o.StateDiff) > 0 {
+		// state and stateDiff can not be overridden at the same time
+		slots := &base.StateDiff
+		if base.State != nil {
+			slots = &base.State
+		}
+		if *slots == nil {
+			*slots = make(map[common.Hash]common.Hash, len(o.StateDiff))
+		}
+		for k, v := range o.StateDiff {
+			(*slots)[k] = v
+		}
+	}
+
+	return base
+}
+
+// applyStateDiff applies the state diff of prestateTracer to overrides.
+func applyStateDiff(state map[common.Address]gethclient.OverrideAccount, pre, post map[common.Address]traceAccount) {
+	for addr, preAcc := range pre {
+		postAcc, ok := post[addr]
+		if !ok {
+			// self destructed
+			state[addr] = gethclient.OverrideAccount{
+				Code:    []byte{},
+				Balance: new(big.Int),
+				State:   map[common.Hash]common.Hash{},
+			}
+			continue
+		}
+
+		o := gethclient.OverrideAccount{
+			Nonce:     postAcc.Nonce,
+			StateDiff: make(map[common.Hash]common.Hash, len(preAcc.Storage)),
+		}
+		if postAcc.Balance != nil {
+			o.Balance = postAcc.Balance.ToInt()
+		}
+		if postAcc.Code != nil {
+			o.Code = *postAcc.Code
+		}
+		// slots cleared are omitted in post
+		for slot := range preAcc.Storage {
+			o.StateDiff[slot] = common.Hash{}
+		}
+		for slot, v := range postAcc.Storage {
+			o.StateDiff[slot] = v
+		}
+		state[addr] = mergeOverride(state[addr], o)
+	}
+
+	// created accounts are only in post
+	for addr, postAcc := range post {
+		if _, ok := pre[addr]; ok {
+			continue
+		}
+		o := gethclient.OverrideAccount{Nonce: postAcc.Nonce, StateDiff: postAcc.Storage}
+		if postAcc.Balance != nil {
+			o.Balance = postAcc.Balance.ToInt()
+		}
+		if postAcc.Code != nil {
+			o.Code = *postAcc.Code
+		}
+		state[addr] = mergeOverride(state[addr], o)
+	}
+}
+
+// revertOf returns the decoded data of json-rpc error, e.g. revert reason or custom error.
+func revertOf(err error) string {
+	var jsonErr *consts.JsonRpcError
+	if !errors.As(err, &jsonErr) {
+		return err.Error()
+	}
+
+	// decoded while formatting
+	_ = jsonErr.Error()
+	if jsonErr.DecodedData == nil {
+		return jsonErr.Message
+	}
+
+	return fmt.Sprint(jsonErr.DecodedData)
+}
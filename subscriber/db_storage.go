@@ -0,0 +1,95 @@
+package subscriber
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strconv"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+var _ SubscriberStorage = (*DBStorage)(nil)
+
+// DBStorage keeps query checkpoints in an embedded key-value database (e.g. pebble or leveldb).
+type DBStorage struct {
+	chainId *big.Int
+	db      ethdb.KeyValueStore
+}
+
+func NewDBStorage(chainId *big.Int, db ethdb.KeyValueStore) *DBStorage {
+	return &DBStorage{
+		chainId: chainId,
+		db:      db,
+	}
+}
+
+func (s *DBStorage) LatestBlockForQuery(ctx context.Context, query ethereum.FilterQuery) (uint64, error) {
+	key := []byte(fmt.Sprintf("latest_block_of_query_%v", GetQueryKey(s.chainId, query)))
+	has, err := s.db.Has(key)
+	if err != nil || !has {
+		return 0, err
+	}
+
+	blockBytes, err := s.db.Get(key)
+	if err != nil {
+		return 0, err
+	}
+
+	return strconv.ParseUint(string(blockBytes), 10, 64)
+}
+
+func (s *DBStorage) LatestLogForQuery(ctx context.Context, query ethereum.FilterQuery) (types.Log, error) {
+	l := types.Log{}
+	key := []byte(fmt.Sprintf("latest_log_of_query_%v", GetQueryKey(s.chainId, query)))
+	has, err := s.db.Has(key)
+	if err != nil || !has {
+		return l, err
+	}
+
+	logBytes, err := s.db.Get(key)
+	if err != nil {
+		return l, err
+	}
+
+	err = l.UnmarshalJSON(logBytes)
+	if err != nil {
+		return l, err
+	}
+
+	return l, nil
+}
+
+func (s *DBStorage) FilterLogs(ctx context.Context, q ethereum.FilterQuery) (logs []types.Log, err error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
+func (s *DBStorage) IsFilterLogsSupported(q ethereum.FilterQuery) bool {
+	return false
+}
+
+func (s *DBStorage) SaveLatestBlockForQuery(ctx context.Context, query ethereum.FilterQuery, blockNum uint64) error {
+	log.Debug("SaveLatestBlockForQuery in db", "query", query, "blockNum", blockNum)
+
+	key := []byte(fmt.Sprintf("latest_block_of_query_%v", GetQueryKey(s.chainId, query)))
+	return s.db.Put(key, []byte(strconv.FormatUint(blockNum, 10)))
+}
+
+func (s *DBStorage) SaveLatestLogForQuery(ctx context.Context, query ethereum.FilterQuery, l types.Log) error {
+	log.Debug("SaveLatestLogForQuery in db", "query", query, "log", l)
+
+	logBytes, err := l.MarshalJSON()
+	if err != nil {
+		return err
+	}
+
+	key := []byte(fmt.Sprintf("latest_log_of_query_%v", GetQueryKey(s.chainId, query)))
+	return s.db.Put(key, logBytes)
+}
+
+func (s *DBStorage) SaveFilterLogs(q ethereum.FilterQuery, logs []types.Log) (err error) {
+	return nil
+}
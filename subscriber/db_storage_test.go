@@ -0,0 +1,78 @@
+package subscriber
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb/pebble"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_DBStorage_Restart(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+	chainId := big.NewInt(1337)
+	query := ethereum.FilterQuery{Addresses: []common.Address{common.HexToAddress("0x1")}}
+
+	db, err := pebble.New(dir, 16, 16, "", false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	storage := NewDBStorage(chainId, db)
+
+	blockNum, err := storage.LatestBlockForQuery(ctx, query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, uint64(0), blockNum)
+
+	err = storage.SaveLatestBlockForQuery(ctx, query, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	l := types.Log{
+		Address:     common.HexToAddress("0x1"),
+		Topics:      []common.Hash{common.HexToHash("0x2")},
+		Data:        []byte{1},
+		BlockNumber: 10,
+		TxHash:      common.HexToHash("0x3"),
+		TxIndex:     1,
+		BlockHash:   common.HexToHash("0x4"),
+		Index:       2,
+	}
+	err = storage.SaveLatestLogForQuery(ctx, query, l)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = db.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	db, err = pebble.New(dir, 16, 16, "", false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	storage = NewDBStorage(chainId, db)
+
+	blockNum, err = storage.LatestBlockForQuery(ctx, query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, uint64(10), blockNum)
+
+	latestLog, err := storage.LatestLogForQuery(ctx, query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, l, latestLog)
+}
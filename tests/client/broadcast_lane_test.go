@@ -0,0 +1,145 @@
+package client_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ivanzzeth/ethclient/message"
+	"github.com/ivanzzeth/ethclient/tests/helper"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_BroadcastLanes(t *testing.T) {
+	sim := helper.SetUpClient(t)
+	defer sim.Close()
+	client := sim.Client()
+
+	chainId, err := client.ChainID(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer := types.LatestSignerForChainID(chainId)
+	// slow signer of Addr2 must not hold up Addr1
+	client.RegisterSigner(func(address common.Address, tx *types.Transaction) (*types.Transaction, error) {
+		if address != helper.Addr2 {
+			return nil, bind.ErrNotAuthorized
+		}
+		time.Sleep(2 * time.Second)
+
+		signature, err := crypto.Sign(signer.Hash(tx).Bytes(), helper.PrivateKey2)
+		if err != nil {
+			return nil, err
+		}
+		return tx.WithSignature(signer, signature)
+	})
+
+	slowReqs := []*message.Request{}
+	for i := 0; i < 3; i++ {
+		req := message.AssignMessageId(&message.Request{From: helper.Addr2, To: &helper.Addr3})
+		client.ScheduleMsg(req)
+		slowReqs = append(slowReqs, req)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+
+	fastReq := message.AssignMessageId(&message.Request{From: helper.Addr1, To: &helper.Addr3})
+	client.ScheduleMsg(fastReq)
+
+	resp, ok := client.WaitMsgResponse(fastReq.Id(), 1500*time.Millisecond)
+	if !ok {
+		t.Fatal("msg of Addr1 was held up by Addr2")
+	}
+	if resp.Err != nil {
+		t.Fatal(resp.Err)
+	}
+
+	for i, req := range slowReqs {
+		resp, ok := client.WaitMsgResponse(req.Id(), 10*time.Second)
+		if !ok {
+			t.Fatal("wait msg response failed")
+		}
+		if resp.Err != nil {
+			t.Fatal(resp.Err)
+		}
+
+		// msgs of the same sender are broadcasted in order
+		assert.Equal(t, uint64(i), resp.Tx.Nonce())
+	}
+
+	// the idle lane was closed, and is opened again for later msgs
+	time.Sleep(100 * time.Millisecond)
+	req := message.AssignMessageId(&message.Request{From: helper.Addr2, To: &helper.Addr3})
+	client.ScheduleMsg(req)
+
+	resp, ok = client.WaitMsgResponse(req.Id(), 5*time.Second)
+	if !ok {
+		t.Fatal("wait msg response failed")
+	}
+	if resp.Err != nil {
+		t.Fatal(resp.Err)
+	}
+	assert.Equal(t, uint64(len(slowReqs)), resp.Tx.Nonce())
+}
+
+func Test_BroadcastLanePriority(t *testing.T) {
+	sim := helper.SetUpClient(t)
+	defer sim.Close()
+	client := sim.Client()
+
+	chainId, err := client.ChainID(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer := types.LatestSignerForChainID(chainId)
+	// the lane of Addr2 is busy while routine msgs are being signed
+	client.RegisterSigner(func(address common.Address, tx *types.Transaction) (*types.Transaction, error) {
+		if address != helper.Addr2 {
+			return nil, bind.ErrNotAuthorized
+		}
+		time.Sleep(200 * time.Millisecond)
+
+		signature, err := crypto.Sign(signer.Hash(tx).Bytes(), helper.PrivateKey2)
+		if err != nil {
+			return nil, err
+		}
+		return tx.WithSignature(signer, signature)
+	})
+
+	routineReqs := []*message.Request{}
+	for i := 0; i < 10; i++ {
+		req := message.AssignMessageId(&message.Request{From: helper.Addr2, To: &helper.Addr3})
+		client.ScheduleMsg(req)
+		routineReqs = append(routineReqs, req)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+
+	urgentReq := message.AssignMessageId(&message.Request{From: helper.Addr2, To: &helper.Addr3, Priority: 1})
+	client.ScheduleMsg(urgentReq)
+
+	resp, ok := client.WaitMsgResponse(urgentReq.Id(), 5*time.Second)
+	if !ok {
+		t.Fatal("urgent msg was held up by the backlog of its sender")
+	}
+	if resp.Err != nil {
+		t.Fatal(resp.Err)
+	}
+
+	// the backlog waits in the sequencer instead of the lane, except msgs popped before the lane was opened
+	assert.LessOrEqual(t, resp.Tx.Nonce(), uint64(2))
+
+	for _, req := range routineReqs {
+		resp, ok := client.WaitMsgResponse(req.Id(), 10*time.Second)
+		if !ok {
+			t.Fatal("wait msg response failed")
+		}
+		if resp.Err != nil {
+			t.Fatal(resp.Err)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package client_test
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ivanzzeth/ethclient/message"
+	"github.com/ivanzzeth/ethclient/tests/helper"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_CancelMsg_Scheduled(t *testing.T) {
+	sim := helper.SetUpClient(t)
+	defer sim.Close()
+	client := sim.Client()
+
+	req := message.AssignMessageId(&message.Request{
+		From:      helper.Addr1,
+		To:        &helper.Addr2,
+		StartTime: time.Now().Add(3 * time.Second).UnixNano(),
+	})
+	client.ScheduleMsg(req)
+
+	time.Sleep(1 * time.Second)
+
+	err := client.CancelMsg(req.Id())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, ok := client.WaitMsgResponse(req.Id(), 5*time.Second)
+	if !ok {
+		t.Fatal("wait msg response failed")
+	}
+	assert.True(t, errors.Is(resp.Err, message.ErrMsgCancelled))
+
+	// make sure that the message was not broadcasted after StartTime
+	time.Sleep(4 * time.Second)
+
+	msg, err := client.GetMsg(req.Id())
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, message.MessageStatusCancelled, msg.Status)
+	assert.Nil(t, msg.Resp.Tx)
+}
+
+func Test_CancelMsg_Inflight(t *testing.T) {
+	sim := helper.SetUpClient(t)
+	defer sim.Close()
+	client := sim.Client()
+
+	req := message.AssignMessageId(&message.Request{
+		From: helper.Addr1,
+		To:   &helper.Addr2,
+	})
+	client.ScheduleMsg(req)
+
+	resp, ok := client.WaitMsgResponse(req.Id(), 5*time.Second)
+	if !ok {
+		t.Fatal("wait msg response failed")
+	}
+	if resp.Err != nil {
+		t.Fatal(resp.Err)
+	}
+
+	err := client.CancelMsg(req.Id())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sim.Commit()
+
+	receipt, ok := client.WaitMsgReceipt(req.Id(), 0, 5*time.Second)
+	if !ok {
+		t.Fatal("wait msg receipt failed")
+	}
+	assert.NotEqual(t, resp.Tx.Hash(), receipt.TxReceipt.TxHash)
+
+	msg, err := client.GetMsg(req.Id())
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, message.MessageStatusCancelled, msg.Status)
+
+	err = client.CancelMsg(req.Id())
+	assert.NotNil(t, err)
+}
+
+func Test_CancelMsg_StuckCancellation(t *testing.T) {
+	sim := helper.SetUpClient(t)
+	defer sim.Close()
+	client := sim.Client()
+
+	req := message.AssignMessageId(&message.Request{
+		From: helper.Addr1,
+		To:   &helper.Addr2,
+	})
+	client.ScheduleMsg(req)
+
+	resp, ok := client.WaitMsgResponse(req.Id(), 5*time.Second)
+	if !ok {
+		t.Fatal("wait msg response failed")
+	}
+	if resp.Err != nil {
+		t.Fatal(resp.Err)
+	}
+
+	err := client.CancelMsg(req.Id())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the cancellation is replaced after the protection timeout
+	time.Sleep(23 * time.Second)
+	sim.Commit()
+
+	receipt, ok := client.WaitMsgReceipt(req.Id(), 0, 5*time.Second)
+	if !ok {
+		t.Fatal("wait msg receipt failed")
+	}
+
+	firstCancelGasPrice := new(big.Int).Div(new(big.Int).Mul(resp.Tx.GasPrice(), big.NewInt(12)), big.NewInt(10))
+	assert.Equal(t, 1, receipt.TxReceipt.EffectiveGasPrice.Cmp(firstCancelGasPrice))
+
+	msg, err := client.GetMsg(req.Id())
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, message.MessageStatusCancelled, msg.Status)
+}
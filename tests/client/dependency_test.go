@@ -0,0 +1,123 @@
+package client_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ivanzzeth/ethclient/message"
+	"github.com/ivanzzeth/ethclient/tests/helper"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_AfterMsgs(t *testing.T) {
+	sim := helper.SetUpClient(t)
+	defer sim.Close()
+	client := sim.Client()
+
+	approve := message.AssignMessageId(&message.Request{From: helper.Addr1, To: &helper.Addr2})
+	deposit := message.AssignMessageId(&message.Request{From: helper.Addr1, To: &helper.Addr2})
+	swap := message.AssignMessageId(&message.Request{
+		From:            helper.Addr1,
+		To:              &helper.Addr2,
+		AfterMsgs:       []common.Hash{approve.Id(), deposit.Id()},
+		AfterConditions: map[common.Hash]message.Condition{approve.Id(): message.AfterSuccess(1)},
+	})
+	compensation := message.AssignMessageId(&message.Request{
+		From:            helper.Addr1,
+		To:              &helper.Addr2,
+		AfterMsgs:       []common.Hash{approve.Id()},
+		AfterConditions: map[common.Hash]message.Condition{approve.Id(): message.AfterFailure},
+	})
+
+	client.ScheduleMsg(swap)
+	client.ScheduleMsg(compensation)
+	client.ScheduleMsg(approve)
+	client.ScheduleMsg(deposit)
+
+	_, ok := client.WaitMsgResponse(deposit.Id(), 5*time.Second)
+	if !ok {
+		t.Fatal("wait deposit response failed")
+	}
+
+	sim.Commit()
+	if _, ok := client.WaitMsgReceipt(approve.Id(), 0, 5*time.Second); !ok {
+		t.Fatal("wait approve receipt failed")
+	}
+
+	// approve has not been confirmed yet
+	time.Sleep(2 * time.Second)
+	msg, err := client.GetMsg(swap.Id())
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Nil(t, msg.Resp)
+
+	sim.Commit()
+
+	resp, ok := client.WaitMsgResponse(swap.Id(), 5*time.Second)
+	if !ok {
+		t.Fatal("wait swap response failed")
+	}
+	assert.Nil(t, resp.Err)
+
+	resp, ok = client.WaitMsgResponse(compensation.Id(), 5*time.Second)
+	if !ok {
+		t.Fatal("wait compensation response failed")
+	}
+	assert.True(t, errors.Is(resp.Err, message.ErrDependencyFailed))
+
+	msg, err = client.GetMsg(compensation.Id())
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, message.MessageStatusSkipped, msg.Status)
+}
+
+func Test_AfterMsgs_FailedPredecessor(t *testing.T) {
+	sim := helper.SetUpClient(t)
+	defer sim.Close()
+	client := sim.Client()
+
+	approve := message.AssignMessageId(&message.Request{
+		From:      helper.Addr1,
+		To:        &helper.Addr2,
+		StartTime: time.Now().Add(time.Hour).UnixNano(),
+	})
+	swap := message.AssignMessageId(&message.Request{
+		From:      helper.Addr1,
+		To:        &helper.Addr2,
+		AfterMsgs: []common.Hash{approve.Id()},
+	})
+	compensation := message.AssignMessageId(&message.Request{
+		From:            helper.Addr1,
+		To:              &helper.Addr2,
+		AfterMsgs:       []common.Hash{approve.Id()},
+		AfterConditions: map[common.Hash]message.Condition{approve.Id(): message.AfterFailure},
+	})
+
+	client.ScheduleMsg(approve)
+	client.ScheduleMsg(swap)
+	client.ScheduleMsg(compensation)
+
+	time.Sleep(time.Second)
+
+	err := client.CancelMsg(approve.Id())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, ok := client.WaitMsgResponse(swap.Id(), 5*time.Second)
+	if !ok {
+		t.Fatal("wait swap response failed")
+	}
+	assert.True(t, errors.Is(resp.Err, message.ErrDependencyFailed))
+
+	resp, ok = client.WaitMsgResponse(compensation.Id(), 5*time.Second)
+	if !ok {
+		t.Fatal("wait compensation response failed")
+	}
+	assert.Nil(t, resp.Err)
+	assert.NotNil(t, resp.Tx)
+}
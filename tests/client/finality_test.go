@@ -0,0 +1,84 @@
+package client_test
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ivanzzeth/ethclient/message"
+	"github.com/ivanzzeth/ethclient/simulated"
+	"github.com/ivanzzeth/ethclient/tests/helper"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_FinalizedByTag(t *testing.T) {
+	sim := helper.SetUpClient(t)
+	defer sim.Close()
+
+	msgId := sendAndMine(t, sim)
+
+	_, ok := sim.Client().WaitMsgFinalized(msgId, 2*time.Second)
+	assert.False(t, ok, "msg not finalized before the finalized block")
+
+	// the simulated beacon finalizes blocks every 32 blocks
+	for i := 0; i < 31; i++ {
+		sim.Commit()
+	}
+
+	receipt, ok := sim.Client().WaitMsgFinalized(msgId, 5*time.Second)
+	if !ok {
+		t.Fatal("wait msg finalized failed")
+	}
+	assert.Equal(t, uint64(1), receipt.TxReceipt.BlockNumber.Uint64())
+}
+
+func Test_FinalizedByDepth(t *testing.T) {
+	sim := helper.SetUpClient(t)
+	defer sim.Close()
+	client := sim.Client()
+
+	client.SetFinalityTag(rpc.LatestBlockNumber)
+	client.SetFinalityDepth(3)
+
+	msgId := sendAndMine(t, sim)
+
+	sim.Commit()
+	sim.Commit()
+	_, ok := client.WaitMsgFinalized(msgId, 2*time.Second)
+	assert.False(t, ok, "msg not finalized before the finality depth")
+
+	sim.Commit()
+	_, ok = client.WaitMsgFinalized(msgId, 5*time.Second)
+	assert.True(t, ok)
+
+	msg, err := client.GetMsg(msgId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, message.MessageStatusFinalized, msg.Status)
+}
+
+func sendAndMine(t *testing.T, sim *simulated.Backend) (msgId common.Hash) {
+	client := sim.Client()
+
+	req := message.AssignMessageId(&message.Request{From: helper.Addr1, To: &helper.Addr2, Value: big.NewInt(1)})
+	client.ScheduleMsg(req)
+
+	resp, ok := client.WaitMsgResponse(req.Id(), 5*time.Second)
+	if !ok {
+		t.Fatal("wait msg response failed")
+	}
+	if resp.Err != nil {
+		t.Fatal(resp.Err)
+	}
+	sim.CommitAndExpectTx(resp.Tx.Hash())
+
+	_, ok = client.WaitMsgReceipt(req.Id(), 0, 5*time.Second)
+	if !ok {
+		t.Fatal("wait msg receipt failed")
+	}
+
+	return req.Id()
+}
@@ -0,0 +1,147 @@
+package client_test
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ivanzzeth/ethclient/message"
+	"github.com/ivanzzeth/ethclient/tests/helper"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_WaitingForFunds(t *testing.T) {
+	sim := helper.SetUpClient(t)
+	defer sim.Close()
+	client := sim.Client()
+	ctx := context.Background()
+
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	poor := crypto.PubkeyToAddress(key.PublicKey)
+	err = client.RegisterPrivateKey(ctx, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	events := make(chan message.LowBalance, 10)
+	client.AddLowBalanceObserver(message.LowBalanceObserverFunc(func(event message.LowBalance) {
+		events <- event
+	}))
+
+	ether := big.NewInt(1e18)
+	fund := func(value *big.Int) {
+		req := message.AssignMessageId(&message.Request{From: helper.Addr1, To: &poor, Value: value})
+		client.ScheduleMsg(req)
+		resp, ok := client.WaitMsgResponse(req.Id(), 5*time.Second)
+		if !ok || resp.Err != nil {
+			t.Fatal("fund account failed", resp)
+		}
+		sim.CommitAndExpectTx(resp.Tx.Hash())
+	}
+
+	// the second msg could not be covered besides the first one in flight
+	fund(new(big.Int).Add(ether, new(big.Int).Div(ether, big.NewInt(2))))
+
+	req1 := message.AssignMessageId(&message.Request{From: poor, To: &helper.Addr2, Value: ether})
+	req2 := message.AssignMessageId(&message.Request{From: poor, To: &helper.Addr2, Value: ether})
+	client.ScheduleMsg(req1)
+	client.ScheduleMsg(req2)
+
+	resp1, ok := client.WaitMsgResponse(req1.Id(), 5*time.Second)
+	if !ok || resp1.Err != nil {
+		t.Fatal("send msg failed", resp1)
+	}
+
+	select {
+	case event := <-events:
+		assert.Equal(t, poor, event.Account)
+		assert.Equal(t, req2.Id(), event.MsgId)
+		assert.Equal(t, resp1.Tx.Cost(), event.InFlight)
+	case <-time.After(5 * time.Second):
+		t.Fatal("no low balance event")
+	}
+
+	msg, err := client.GetMsg(req2.Id())
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, message.MessageStatusWaitingForFunds, msg.Status)
+	assert.Nil(t, msg.Resp)
+
+	sim.CommitAndExpectTx(resp1.Tx.Hash())
+	_, ok = client.WaitMsgReceipt(req1.Id(), 0, 5*time.Second)
+	if !ok {
+		t.Fatal("wait receipt failed")
+	}
+
+	// still underfunded after the balance changed
+	select {
+	case <-events:
+	case <-time.After(5 * time.Second):
+		t.Fatal("no low balance event after retry")
+	}
+
+	fund(ether)
+
+	resp2, ok := client.WaitMsgResponse(req2.Id(), 5*time.Second)
+	if !ok || resp2.Err != nil {
+		t.Fatal("send msg after funded failed", resp2)
+	}
+	assert.Equal(t, uint64(1), resp2.Tx.Nonce(), "nonce not burned")
+}
+
+func Test_FailedBroadcastReleasesFunds(t *testing.T) {
+	sim := helper.SetUpClient(t)
+	defer sim.Close()
+	client := sim.Client()
+	ctx := context.Background()
+
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	poor := crypto.PubkeyToAddress(key.PublicKey)
+
+	ether := big.NewInt(1e18)
+	fundReq := message.AssignMessageId(&message.Request{From: helper.Addr1, To: &poor, Value: new(big.Int).Add(ether, new(big.Int).Div(ether, big.NewInt(2)))})
+	client.ScheduleMsg(fundReq)
+	resp, ok := client.WaitMsgResponse(fundReq.Id(), 5*time.Second)
+	if !ok || resp.Err != nil {
+		t.Fatal("fund account failed", resp)
+	}
+	sim.CommitAndExpectTx(resp.Tx.Hash())
+
+	// failed to be signed, since the key was not registered yet
+	failed := message.AssignMessageId(&message.Request{From: poor, To: &helper.Addr2, Value: ether})
+	client.ScheduleMsg(failed)
+	resp, ok = client.WaitMsgResponse(failed.Id(), 5*time.Second)
+	if !ok {
+		t.Fatal("wait msg response failed")
+	}
+	assert.NotNil(t, resp.Err)
+
+	msg, err := client.GetMsg(failed.Id())
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, message.MessageStatusNonceReleased, msg.Status)
+
+	err = client.RegisterPrivateKey(ctx, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// funds reserved by the failed msg were given back
+	req := message.AssignMessageId(&message.Request{From: poor, To: &helper.Addr2, Value: ether})
+	client.ScheduleMsg(req)
+	resp, ok = client.WaitMsgResponse(req.Id(), 5*time.Second)
+	if !ok || resp.Err != nil {
+		t.Fatal("send msg failed", resp)
+	}
+	assert.Equal(t, uint64(0), resp.Tx.Nonce())
+}
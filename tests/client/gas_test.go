@@ -0,0 +1,81 @@
+package client_test
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ivanzzeth/ethclient/contracts"
+	"github.com/ivanzzeth/ethclient/gas"
+	"github.com/ivanzzeth/ethclient/message"
+	"github.com/ivanzzeth/ethclient/tests/helper"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_GasLimitEstimator(t *testing.T) {
+	sim := helper.SetUpClient(t)
+	defer sim.Close()
+	client := sim.Client()
+	ctx := context.Background()
+
+	contractAddr, _, _ := helper.DeployTestContract(t, ctx, sim)
+	data, err := client.NewMethodData(contracts.GetTestContractABI(), "testFunc1", "a", big.NewInt(1), []byte{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	send := func() (gasLimit, gasUsed uint64) {
+		req := message.AssignMessageId(&message.Request{From: helper.Addr1, To: &contractAddr, Data: data})
+		client.ScheduleMsg(req)
+
+		resp, ok := client.WaitMsgResponse(req.Id(), 5*time.Second)
+		if !ok {
+			t.Fatal("wait msg response failed")
+		}
+		if resp.Err != nil {
+			t.Fatal(resp.Err)
+		}
+		sim.CommitAndExpectTx(resp.Tx.Hash())
+
+		receipt, ok := client.WaitMsgReceipt(req.Id(), 0, 5*time.Second)
+		if !ok {
+			t.Fatal("wait msg receipt failed")
+		}
+
+		return resp.Tx.Gas(), receipt.TxReceipt.GasUsed
+	}
+
+	// the estimation is multiplied only once by default
+	estimated, err := client.RawClient().EstimateGas(ctx, ethereum.CallMsg{From: helper.Addr1, To: &contractAddr, Data: data})
+	if err != nil {
+		t.Fatal(err)
+	}
+	gasLimit, _ := send()
+	assert.Equal(t, estimated*15/10, gasLimit)
+
+	storage := gas.NewMemoryStorage()
+	estimator := gas.NewLimitEstimator(client.RawClient(), storage)
+	estimator.SetMinSamples(2)
+	client.SetGasEstimator(estimator)
+
+	// not learned yet
+	estimated, err = client.RawClient().EstimateGas(ctx, ethereum.CallMsg{From: helper.Addr1, To: &contractAddr, Data: data})
+	if err != nil {
+		t.Fatal(err)
+	}
+	gasLimit, gasUsed1 := send()
+	assert.Equal(t, estimated*110/100, gasLimit)
+
+	_, gasUsed2 := send()
+
+	key := gas.Key{To: contractAddr, Selector: [4]byte(data[:4])}
+	assert.Eventually(t, func() bool {
+		samples, _ := storage.GetGasUsed(key)
+		return len(samples) == 2
+	}, 5*time.Second, 100*time.Millisecond)
+
+	gasLimit, _ = send()
+	assert.Equal(t, max(gasUsed1, gasUsed2)*110/100, gasLimit, "learned from receipts")
+}
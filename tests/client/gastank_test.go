@@ -0,0 +1,76 @@
+package client_test
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ivanzzeth/ethclient/gastank"
+	"github.com/ivanzzeth/ethclient/message"
+	"github.com/ivanzzeth/ethclient/tests/helper"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_GasTankTopUp(t *testing.T) {
+	sim := helper.SetUpClient(t)
+	defer sim.Close()
+	client := sim.Client()
+	ctx := context.Background()
+
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	hot := crypto.PubkeyToAddress(key.PublicKey)
+
+	ether := big.NewInt(1e18)
+	tank, err := gastank.NewGasTank(client, gastank.Config{
+		Treasury:  helper.Addr1,
+		Threshold: ether,
+		Target:    new(big.Int).Mul(ether, big.NewInt(2)),
+		DailyCap:  new(big.Int).Mul(ether, big.NewInt(10)),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tank.Close()
+	tank.SetCheckInterval(100 * time.Millisecond)
+	client.AddLowBalanceObserver(tank)
+	tank.Watch(hot)
+
+	var topUp message.Message
+	assert.Eventually(t, func() bool {
+		msgs, _, _ := client.ListMsgs(message.MsgFilter{Labels: map[string]string{gastank.LabelKey: gastank.LabelTopUp}})
+		if len(msgs) == 0 {
+			return false
+		}
+		topUp = msgs[0]
+		return true
+	}, 5*time.Second, 100*time.Millisecond)
+
+	resp, ok := client.WaitMsgResponse(topUp.Id(), 5*time.Second)
+	if !ok || resp.Err != nil {
+		t.Fatal("top up failed", resp)
+	}
+	sim.CommitAndExpectTx(resp.Tx.Hash())
+	_, ok = client.WaitMsgReceipt(topUp.Id(), 0, 5*time.Second)
+	if !ok {
+		t.Fatal("wait receipt of top-up failed")
+	}
+
+	balance, err := client.BalanceAt(ctx, hot, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, new(big.Int).Mul(ether, big.NewInt(2)), balance)
+
+	// not topped up again
+	time.Sleep(500 * time.Millisecond)
+	msgs, _, err := client.ListMsgs(message.MsgFilter{Labels: map[string]string{gastank.LabelKey: gastank.LabelTopUp}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, msgs, 1)
+}
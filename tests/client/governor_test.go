@@ -0,0 +1,46 @@
+package client_test
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ivanzzeth/ethclient/message"
+	"github.com/ivanzzeth/ethclient/tests/helper"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_GovernorMaxUnconfirmed(t *testing.T) {
+	sim := helper.SetUpClient(t)
+	defer sim.Close()
+	client := sim.Client()
+
+	client.SetGovernor(message.NewThroughputGovernor(message.ThroughputLimits{MaxUnconfirmed: 1}))
+
+	reqs := make([]*message.Request, 3)
+	for i := range reqs {
+		reqs[i] = message.AssignMessageId(&message.Request{From: helper.Addr1, To: &helper.Addr2, Value: big.NewInt(int64(i + 1))})
+		client.ScheduleMsg(reqs[i])
+	}
+
+	for i, req := range reqs {
+		resp, ok := client.WaitMsgResponse(req.Id(), 5*time.Second)
+		if !ok {
+			t.Fatalf("wait response of msg %d failed", i)
+		}
+		if resp.Err != nil {
+			t.Fatal(resp.Err)
+		}
+
+		if i+1 < len(reqs) {
+			_, ok = client.WaitMsgResponse(reqs[i+1].Id(), 2*time.Second)
+			assert.False(t, ok, "msg %d waits for msg %d on-chain", i+1, i)
+		}
+
+		sim.CommitAndExpectTx(resp.Tx.Hash())
+		_, ok = client.WaitMsgReceipt(req.Id(), 0, 5*time.Second)
+		if !ok {
+			t.Fatalf("wait receipt of msg %d failed", i)
+		}
+	}
+}
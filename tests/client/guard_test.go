@@ -0,0 +1,91 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ivanzzeth/ethclient/contracts"
+	"github.com/ivanzzeth/ethclient/message"
+	"github.com/ivanzzeth/ethclient/tests/helper"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Guard(t *testing.T) {
+	sim := helper.SetUpClient(t)
+	defer sim.Close()
+	client := sim.Client()
+	ctx := context.Background()
+
+	contractAddr, _, _ := helper.DeployTestContract(t, ctx, sim)
+	contractAbi := contracts.GetTestContractABI()
+
+	counterData, err := client.NewMethodData(contractAbi, "counter")
+	if err != nil {
+		t.Fatal(err)
+	}
+	incrData, err := client.NewMethodData(contractAbi, "testFunc1", "hello", big.NewInt(1), []byte{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// send only if counter >= 1
+	guard := func(policy message.GuardPolicy) *message.Guard {
+		return &message.Guard{
+			To:            contractAddr,
+			Data:          counterData,
+			Op:            message.GuardGte,
+			Expected:      []byte{1},
+			Policy:        policy,
+			RetryInterval: 500 * time.Millisecond,
+		}
+	}
+
+	skipped := message.AssignMessageId(&message.Request{From: helper.Addr1, To: &helper.Addr2, Guard: guard(message.GuardSkip)})
+	expired := message.AssignMessageId(&message.Request{From: helper.Addr1, To: &helper.Addr2, Guard: guard(message.GuardExpire)})
+	retried := message.AssignMessageId(&message.Request{From: helper.Addr1, To: &helper.Addr2, Guard: guard(message.GuardRetry)})
+
+	client.ScheduleMsg(skipped)
+	client.ScheduleMsg(expired)
+	client.ScheduleMsg(retried)
+
+	for id, status := range map[*message.Request]message.MessageStatus{
+		skipped: message.MessageStatusSkipped,
+		expired: message.MessageStatusExpired,
+	} {
+		resp, ok := client.WaitMsgResponse(id.Id(), 5*time.Second)
+		if !ok {
+			t.Fatal("wait msg response failed")
+		}
+		assert.True(t, errors.Is(resp.Err, message.ErrGuardNotMet))
+
+		msg, err := client.GetMsg(id.Id())
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, status, msg.Status)
+	}
+
+	msg, err := client.GetMsg(retried.Id())
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Nil(t, msg.Resp)
+
+	incr := message.AssignMessageId(&message.Request{From: helper.Addr1, To: &contractAddr, Data: incrData})
+	client.ScheduleMsg(incr)
+	resp, ok := client.WaitMsgResponse(incr.Id(), 5*time.Second)
+	if !ok {
+		t.Fatal("wait msg response failed")
+	}
+	sim.CommitAndExpectTx(resp.Tx.Hash())
+
+	resp, ok = client.WaitMsgResponse(retried.Id(), 5*time.Second)
+	if !ok {
+		t.Fatal("guarded msg was not retried")
+	}
+	assert.Nil(t, resp.Err)
+	assert.NotNil(t, resp.Tx)
+}
@@ -0,0 +1,80 @@
+package client_test
+
+import (
+	"context"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ivanzzeth/ethclient/message"
+	"github.com/ivanzzeth/ethclient/tests/helper"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_IdempotencyKey(t *testing.T) {
+	sim := helper.SetUpClient(t)
+	defer sim.Close()
+	client := sim.Client()
+
+	newReq := func() *message.Request {
+		return &message.Request{
+			From:           helper.Addr1,
+			To:             &helper.Addr2,
+			Value:          big.NewInt(1),
+			IdempotencyKey: "payout-1",
+		}
+	}
+
+	// retried by clients concurrently
+	var wg sync.WaitGroup
+	ids := make([]common.Hash, 5)
+	for i := range ids {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			msg, err := client.SubmitMsg(newReq())
+			assert.Nil(t, err)
+			ids[i] = msg.Id()
+		}(i)
+	}
+	wg.Wait()
+
+	msgId := *message.GenerateMessageIdByIdempotencyKey(helper.Addr1, "payout-1")
+	for _, id := range ids {
+		assert.Equal(t, msgId, id)
+	}
+
+	resp, ok := client.WaitMsgResponse(msgId, 5*time.Second)
+	if !ok {
+		t.Fatal("wait msg response failed")
+	}
+	assert.Nil(t, resp.Err)
+	sim.CommitAndExpectTx(resp.Tx.Hash())
+
+	_, ok = client.WaitMsgReceipt(msgId, 0, 5*time.Second)
+	if !ok {
+		t.Fatal("wait msg receipt failed")
+	}
+
+	// submitted again after being sent
+	req := newReq()
+	client.ScheduleMsg(req)
+	assert.Equal(t, msgId, req.Id())
+
+	msg, err := client.SubmitMsg(newReq())
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, message.MessageStatusOnChain, msg.Status)
+	assert.Equal(t, resp.Tx.Hash(), msg.Resp.Tx.Hash())
+
+	time.Sleep(2 * time.Second)
+	nonce, err := client.Client.PendingNonceAt(context.Background(), helper.Addr1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, uint64(1), nonce, "sent only once")
+}
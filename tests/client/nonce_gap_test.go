@@ -0,0 +1,127 @@
+package client_test
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ivanzzeth/ethclient/nonce"
+	"github.com/ivanzzeth/ethclient/tests/helper"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ReleaseNonce(t *testing.T) {
+	sim := helper.SetUpClient(t)
+	defer sim.Close()
+	client := sim.Client()
+	ctx := context.Background()
+
+	nm, err := nonce.NewSimpleManager(client.Client, nonce.NewMemoryStorage())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 3; i++ {
+		n, err := nm.PendingNonceAt(ctx, helper.Addr1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, uint64(i), n)
+	}
+
+	err = nm.ReleaseNonce(ctx, helper.Addr1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n, err := nm.PendingNonceAt(ctx, helper.Addr1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, uint64(1), n, "released nonce should be reused")
+
+	err = nm.ReleaseNonce(ctx, helper.Addr1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = nm.ReleaseNonce(ctx, helper.Addr1, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	next, err := nm.PeekNonce(helper.Addr1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, uint64(1), next, "released nonces at the tail should be rolled back")
+}
+
+func Test_NonceGapChecker(t *testing.T) {
+	sim := helper.SetUpClient(t)
+	defer sim.Close()
+	client := sim.Client()
+	ctx := context.Background()
+
+	nm, err := nonce.NewSimpleManager(client.Client, nonce.NewMemoryStorage())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	checker := nonce.NewGapChecker(client.Client, nm, client.GetSigner())
+
+	// nonce 0 was burned
+	_, err = nm.PendingNonceAt(ctx, helper.Addr1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n, err := nm.PendingNonceAt(ctx, helper.Addr1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gasPrice, err := nm.SuggestGasPrice(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tx, err := client.GetSigner()(helper.Addr1, types.NewTransaction(n, helper.Addr2, big.NewInt(1), 21000, gasPrice, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = client.SendTransaction(ctx, tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// first check only marks the gap
+	err = checker.Check(ctx, helper.Addr1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pending, err := client.PendingNonceAt(ctx, helper.Addr1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, uint64(0), pending)
+
+	err = checker.Check(ctx, helper.Addr1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sim.Commit()
+
+	_, ok := client.WaitTxReceipt(tx.Hash(), 0, 5*time.Second)
+	assert.True(t, ok, "stalled tx should be mined after gap filled")
+
+	latest, err := client.NonceAt(ctx, helper.Addr1, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, uint64(2), latest)
+}
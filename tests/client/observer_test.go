@@ -0,0 +1,51 @@
+package client_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ivanzzeth/ethclient/message"
+	"github.com/ivanzzeth/ethclient/tests/helper"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_MsgObserver(t *testing.T) {
+	sim := helper.SetUpClient(t)
+	defer sim.Close()
+	client := sim.Client()
+
+	var (
+		lock     sync.Mutex
+		statuses []message.MessageStatus
+		receipt  *message.Receipt
+	)
+	client.AddMsgObserver(message.MessageObserverFunc(func(oldStatus, newStatus message.MessageStatus, msg message.Message) {
+		lock.Lock()
+		defer lock.Unlock()
+
+		if oldStatus != newStatus {
+			statuses = append(statuses, newStatus)
+		}
+		receipt = msg.Receipt
+	}))
+
+	msgId := sendAndMine(t, sim)
+
+	assert.Eventually(t, func() bool {
+		lock.Lock()
+		defer lock.Unlock()
+		return receipt != nil
+	}, 5*time.Second, 100*time.Millisecond)
+
+	lock.Lock()
+	defer lock.Unlock()
+	assert.Equal(t, []message.MessageStatus{
+		message.MessageStatusScheduled,
+		message.MessageStatusQueued,
+		message.MessageStatusNonceAssigned,
+		message.MessageStatusInflight,
+		message.MessageStatusOnChain,
+	}, statuses)
+	assert.Equal(t, msgId, receipt.Id)
+}
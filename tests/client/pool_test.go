@@ -0,0 +1,255 @@
+package client_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"math/big"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ivanzzeth/ethclient/message"
+	"github.com/ivanzzeth/ethclient/tests/helper"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_SenderPool(t *testing.T) {
+	sim := helper.SetUpClient(t)
+	defer sim.Close()
+	client := sim.Client()
+	ctx := context.Background()
+
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	poor := crypto.PubkeyToAddress(key.PublicKey)
+
+	for _, k := range []*ecdsa.PrivateKey{helper.PrivateKey2, helper.PrivateKey3, key} {
+		err = client.RegisterPrivateKey(ctx, k)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the poor account could not cover the value
+	client.SetSenderPool("hot", poor, helper.Addr2, helper.Addr3)
+
+	var reqs []*message.Request
+	for i := 0; i < 4; i++ {
+		req := message.AssignMessageId(&message.Request{Pool: "hot", To: &helper.Addr4, Value: common.Big1})
+		client.ScheduleMsg(req)
+		reqs = append(reqs, req)
+	}
+
+	sent := make(map[common.Address]int)
+	var txHashes []common.Hash
+	for _, req := range reqs {
+		resp, ok := client.WaitMsgResponse(req.Id(), 5*time.Second)
+		if !ok || resp.Err != nil {
+			t.Fatal("send msg failed", resp)
+		}
+
+		sender, err := types.Sender(types.LatestSignerForChainID(resp.Tx.ChainId()), resp.Tx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sent[sender]++
+		txHashes = append(txHashes, resp.Tx.Hash())
+
+		msg, err := client.GetMsg(req.Id())
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, sender, msg.Req.From)
+		assert.Equal(t, "hot", msg.Req.Pool)
+	}
+
+	// msgs are spread across funded accounts while the former ones are still in flight
+	assert.Equal(t, map[common.Address]int{helper.Addr2: 2, helper.Addr3: 2}, sent)
+
+	sim.CommitAndExpectTx(txHashes[0])
+	for _, req := range reqs {
+		_, ok := client.WaitMsgReceipt(req.Id(), 0, 5*time.Second)
+		if !ok {
+			t.Fatal("wait receipt failed")
+		}
+	}
+
+	req := message.AssignMessageId(&message.Request{Pool: "cold", To: &helper.Addr4})
+	client.ScheduleMsg(req)
+	resp, ok := client.WaitMsgResponse(req.Id(), 5*time.Second)
+	if !ok {
+		t.Fatal("wait response failed")
+	}
+	assert.True(t, errors.Is(resp.Err, message.ErrUnknownSenderPool))
+
+	// the idempotency key is scoped by the pool, since the sender is not picked yet
+	var ids []common.Hash
+	for _, pool := range []string{"hot", "hot", "warm"} {
+		msg, err := client.SubmitMsg(&message.Request{Pool: pool, To: &helper.Addr4, IdempotencyKey: "payout-1"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, msg.Id())
+	}
+	assert.Equal(t, ids[0], ids[1])
+	assert.NotEqual(t, ids[0], ids[2])
+}
+
+type governorFunc func(req message.Request) bool
+
+func (f governorFunc) Allow(req message.Request) bool {
+	return f(req)
+}
+
+func Test_SenderPool_WaitingForFunds(t *testing.T) {
+	sim := helper.SetUpClient(t)
+	defer sim.Close()
+	client := sim.Client()
+	ctx := context.Background()
+
+	var accounts []common.Address
+	for i := 0; i < 2; i++ {
+		key, err := crypto.GenerateKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = client.RegisterPrivateKey(ctx, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		accounts = append(accounts, crypto.PubkeyToAddress(key.PublicKey))
+	}
+	client.SetSenderPool("hot", accounts...)
+
+	popped := make(chan common.Address, 10)
+	client.SetGovernor(governorFunc(func(req message.Request) bool {
+		if req.Pool != "" {
+			popped <- req.From
+		}
+		return true
+	}))
+
+	ether := big.NewInt(1e18)
+	fund := func(account common.Address, value *big.Int) {
+		req := message.AssignMessageId(&message.Request{From: helper.Addr1, To: &account, Value: value})
+		client.ScheduleMsg(req)
+		resp, ok := client.WaitMsgResponse(req.Id(), 5*time.Second)
+		if !ok || resp.Err != nil {
+			t.Fatal("fund account failed", resp)
+		}
+		sim.CommitAndExpectTx(resp.Tx.Hash())
+	}
+
+	// neither account could cover the msg
+	fund(accounts[0], new(big.Int).Div(ether, big.NewInt(2)))
+
+	req := message.AssignMessageId(&message.Request{Pool: "hot", To: &helper.Addr4, Value: ether})
+	client.ScheduleMsg(req)
+
+	assert.Eventually(t, func() bool {
+		msg, err := client.GetMsg(req.Id())
+		return err == nil && msg.Status == message.MessageStatusWaitingForFunds
+	}, 5*time.Second, 100*time.Millisecond)
+
+	// the account with less funds is topped up
+	fund(accounts[1], new(big.Int).Mul(ether, big.NewInt(2)))
+
+	resp, ok := client.WaitMsgResponse(req.Id(), 10*time.Second)
+	if !ok || resp.Err != nil {
+		t.Fatal("send msg failed", resp)
+	}
+
+	sender, err := types.Sender(types.LatestSignerForChainID(resp.Tx.ChainId()), resp.Tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, accounts[1], sender)
+
+	// the governor sees the sender picked
+	select {
+	case from := <-popped:
+		assert.Equal(t, accounts[1], from)
+	default:
+		t.Fatal("msg of pool not governed")
+	}
+}
+
+func Test_SenderPool_Reassign(t *testing.T) {
+	sim := helper.SetUpClient(t)
+	defer sim.Close()
+	client := sim.Client()
+	ctx := context.Background()
+
+	var accounts []common.Address
+	for i := 0; i < 2; i++ {
+		key, err := crypto.GenerateKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = client.RegisterPrivateKey(ctx, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		accounts = append(accounts, crypto.PubkeyToAddress(key.PublicKey))
+	}
+	client.SetSenderPool("hot", accounts...)
+
+	ether := big.NewInt(1e18)
+	for _, account := range accounts {
+		req := message.AssignMessageId(&message.Request{From: helper.Addr1, To: &account, Value: new(big.Int).Mul(ether, big.NewInt(2))})
+		client.ScheduleMsg(req)
+		resp, ok := client.WaitMsgResponse(req.Id(), 5*time.Second)
+		if !ok || resp.Err != nil {
+			t.Fatal("fund account failed", resp)
+		}
+		sim.CommitAndExpectTx(resp.Tx.Hash())
+	}
+
+	// msgs of the pool are held in the sequencer after their sender was picked
+	var held atomic.Bool
+	held.Store(true)
+	client.SetGovernor(governorFunc(func(req message.Request) bool {
+		return req.Pool == "" || !held.Load()
+	}))
+
+	req := message.AssignMessageId(&message.Request{Pool: "hot", To: &helper.Addr4, Value: ether})
+	client.ScheduleMsg(req)
+
+	assert.Eventually(t, func() bool {
+		msg, err := client.GetMsg(req.Id())
+		return err == nil && msg.Status == message.MessageStatusQueued && msg.Req.From == accounts[0]
+	}, 5*time.Second, 100*time.Millisecond)
+
+	// the account picked could no longer cover the msg once it's released
+	spend := message.AssignMessageId(&message.Request{From: accounts[0], To: &helper.Addr4, Value: new(big.Int).Div(new(big.Int).Mul(ether, big.NewInt(3)), big.NewInt(2))})
+	client.ScheduleMsg(spend)
+	resp, ok := client.WaitMsgResponse(spend.Id(), 5*time.Second)
+	if !ok || resp.Err != nil {
+		t.Fatal("send msg failed", resp)
+	}
+
+	held.Store(false)
+
+	resp, ok = client.WaitMsgResponse(req.Id(), 5*time.Second)
+	if !ok || resp.Err != nil {
+		t.Fatal("msg of pool was not reassigned", resp)
+	}
+
+	sender, err := types.Sender(types.LatestSignerForChainID(resp.Tx.ChainId()), resp.Tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, accounts[1], sender)
+
+	msg, err := client.GetMsg(req.Id())
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, accounts[1], msg.Req.From)
+}
@@ -0,0 +1,222 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb/memorydb"
+	"github.com/ivanzzeth/ethclient"
+	"github.com/ivanzzeth/ethclient/account"
+	"github.com/ivanzzeth/ethclient/message"
+	"github.com/ivanzzeth/ethclient/nonce"
+	"github.com/ivanzzeth/ethclient/subscriber"
+	"github.com/ivanzzeth/ethclient/tests/helper"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_RecoverMsgs(t *testing.T) {
+	sim := helper.SetUpClient(t)
+	defer sim.Close()
+	client := sim.Client()
+	ctx := context.Background()
+
+	chainId, err := client.ChainID(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// messages left by the crashed process, and a peer sharing the storage
+	msgStore := message.NewDBStorage(chainId, memorydb.New())
+	labels := map[string]string{"instance": "a"}
+
+	peer := message.AssignMessageId(&message.Request{From: helper.Addr1, To: &helper.Addr2, Labels: map[string]string{"instance": "b"}})
+	err = msgStore.AddMsg(*peer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	submitted := message.AssignMessageId(&message.Request{From: helper.Addr1, To: &helper.Addr2, Labels: labels})
+	err = msgStore.AddMsg(*submitted)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	inflight := message.AssignMessageId(&message.Request{From: helper.Addr1, To: &helper.Addr2, Value: big.NewInt(1), Labels: labels})
+	err = msgStore.AddMsg(*inflight)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gasPrice, err := client.SuggestGasPrice(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx, err := client.GetSigner()(helper.Addr1, types.NewTransaction(0, helper.Addr2, big.NewInt(1), 21000, gasPrice, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = client.SendTransaction(ctx, tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sim.CommitAndExpectTx(tx.Hash())
+
+	err = msgStore.UpdateResponse(inflight.Id(), message.Response{Id: inflight.Id(), Tx: tx})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = msgStore.UpdateMsgStatus(inflight.Id(), message.MessageStatusInflight)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	interrupted := message.AssignMessageId(&message.Request{From: helper.Addr1, To: &helper.Addr2, Labels: labels})
+	err = msgStore.AddMsg(*interrupted)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = msgStore.UpdateMsgStatus(interrupted.Id(), message.MessageStatusNonceAssigned)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// crashed after the tx was broadcasted, or signed only, but before the response was saved
+	broadcasted, broadcastedTx := addSignedMsg(t, client, msgStore, labels, 1, gasPrice)
+	err = client.SendTransaction(ctx, broadcastedTx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	unsent, unsentTx := addSignedMsg(t, client, msgStore, labels, 2, gasPrice)
+
+	failed := message.AssignMessageId(&message.Request{From: helper.Addr1, To: &helper.Addr2, Labels: labels})
+	err = msgStore.AddMsg(*failed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = msgStore.UpdateMsgStatus(failed.Id(), message.MessageStatusNonceAssigned)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = msgStore.UpdateResponse(failed.Id(), message.Response{Id: failed.Id(), Err: errors.New("SendTransaction err")})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// restart
+	rpcClient := client.RpcClient()
+	accRegistry := account.NewSimpleRegistry(chainId)
+	err = accRegistry.RegisterPrivateKey(ctx, helper.PrivateKey1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// nonces are persisted as well, the next one is after those signed
+	nonceStore := nonce.NewMemoryStorage()
+	err = nonceStore.SetNonce(helper.Addr1, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	nm, err := nonce.NewSimpleManager(client.Client, nonceStore)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sub, err := subscriber.NewChainSubscriber(rpcClient, subscriber.NewMemoryStorage(chainId))
+	if err != nil {
+		t.Fatal(err)
+	}
+	msgManager := message.NewSimpleManager(client.Client, nm, accRegistry, msgStore)
+	sequencer := message.NewMemorySequencer(client.Client, msgStore, 10)
+
+	recovered, err := ethclient.NewEthClient(rpcClient, accRegistry, msgStore, nm, msgManager, sub, sequencer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = recovered.RecoverMsgs(ctx, message.MsgFilter{
+		Labels:       labels,
+		CreatedAfter: time.Now().Add(-time.Hour).UnixNano(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, ok := recovered.WaitMsgResponse(submitted.Id(), 5*time.Second)
+	if !ok {
+		t.Fatal("submitted msg was not recovered")
+	}
+	assert.Nil(t, resp.Err)
+	assert.NotNil(t, resp.Tx)
+
+	msg, err := recovered.GetMsg(inflight.Id())
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, message.MessageStatusOnChain, msg.Status)
+	assert.Equal(t, tx.Hash(), msg.Receipt.TxReceipt.TxHash)
+
+	msg, err = recovered.GetMsg(interrupted.Id())
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, message.MessageStatusNonceReleased, msg.Status)
+	assert.True(t, errors.Is(msg.Resp.Err, message.ErrMsgInterrupted))
+
+	msg, err = recovered.GetMsg(failed.Id())
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, message.MessageStatusNonceReleased, msg.Status)
+
+	// signed txs are sent as they were instead of being signed again
+	for _, id := range []common.Hash{broadcasted.Id(), unsent.Id()} {
+		msg, err = recovered.GetMsg(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, message.MessageStatusInflight, msg.Status)
+	}
+	sim.Commit()
+	for _, tx := range []*types.Transaction{broadcastedTx, unsentTx} {
+		_, err = recovered.TransactionReceipt(ctx, tx.Hash())
+		assert.Nil(t, err, "tx %v was not on-chain", tx.Hash().Hex())
+	}
+
+	// msgs of the peer are left to itself
+	msg, err = recovered.GetMsg(peer.Id())
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, message.MessageStatusSubmitted, msg.Status)
+	assert.Nil(t, msg.Resp)
+}
+
+// addSignedMsg adds a msg assigned the nonce, which was signed but not responded.
+func addSignedMsg(t *testing.T, client *ethclient.Client, msgStore message.Storage, labels map[string]string, nonce uint64, gasPrice *big.Int) (*message.Request, *types.Transaction) {
+	req := message.AssignMessageId(&message.Request{From: helper.Addr1, To: &helper.Addr2, Value: big.NewInt(1), Labels: labels})
+	err := msgStore.AddMsg(*req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tx, err := client.GetSigner()(helper.Addr1, types.NewTransaction(nonce, helper.Addr2, big.NewInt(1), 21000, gasPrice, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msg, err := msgStore.GetMsg(req.Id())
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg.SignedTx = tx
+	msg.Status = message.MessageStatusNonceAssigned
+	err = msgStore.UpdateMsg(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return req, tx
+}
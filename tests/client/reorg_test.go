@@ -0,0 +1,72 @@
+package client_test
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ivanzzeth/ethclient/message"
+	"github.com/ivanzzeth/ethclient/tests/helper"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ReorgedMsg(t *testing.T) {
+	sim := helper.SetUpClient(t)
+	defer sim.Close()
+	client := sim.Client()
+	ctx := context.Background()
+
+	genesis, err := client.HeaderByNumber(ctx, big.NewInt(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := message.AssignMessageId(&message.Request{From: helper.Addr1, To: &helper.Addr2, Value: big.NewInt(1)})
+	client.ScheduleMsg(req)
+
+	resp, ok := client.WaitMsgResponse(req.Id(), 5*time.Second)
+	if !ok {
+		t.Fatal("wait msg response failed")
+	}
+	assert.Nil(t, resp.Err)
+	sim.CommitAndExpectTx(resp.Tx.Hash())
+
+	mined, ok := client.WaitMsgReceipt(req.Id(), 0, 5*time.Second)
+	if !ok {
+		t.Fatal("wait msg receipt failed")
+	}
+
+	// the block of msg is replaced by a longer side chain
+	err = sim.Fork(genesis.Hash())
+	if err != nil {
+		t.Fatal(err)
+	}
+	sim.Commit()
+	sim.Commit()
+
+	var msg message.Message
+	for i := 0; i < 20; i++ {
+		time.Sleep(time.Second)
+		sim.Commit()
+
+		msg, err = client.GetMsg(req.Id())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if msg.Receipt != nil && msg.Receipt.TxReceipt.BlockHash != mined.TxReceipt.BlockHash {
+			break
+		}
+	}
+
+	assert.Equal(t, message.MessageStatusOnChain, msg.Status)
+	if assert.NotNil(t, msg.Receipt, "msg mined again after reorg") {
+		assert.NotEqual(t, mined.TxReceipt.BlockHash, msg.Receipt.TxReceipt.BlockHash)
+
+		receipt, err := client.TransactionReceipt(ctx, resp.Tx.Hash())
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, receipt.BlockHash, msg.Receipt.TxReceipt.BlockHash)
+	}
+}
@@ -106,7 +106,8 @@ func testScheduleMsg(t *testing.T, sim *simulated.Backend, concurrent bool) {
 			t.Fatal("get msg failed: ", err)
 		}
 
-		if msg.Status != message.MessageStatusInflight {
+		// msgs may have been mined with the previous block
+		if msg.Status != message.MessageStatusInflight && msg.Status != message.MessageStatusOnChain {
 			t.Fatal("unexpected msg status: ", msg.Status)
 		}
 
@@ -127,7 +128,10 @@ func testScheduleMsg(t *testing.T, sim *simulated.Backend, concurrent bool) {
 			t.Fatal("get msg failed: ", err)
 		}
 
-		// if msg.Status != message.MessageStatusOnChain
+		if msg.Status != message.MessageStatusOnChain {
+			t.Fatal("unexpected msg status: ", msg.Status)
+		}
+
 		if msg.Receipt == nil {
 			t.Fatalf("get msg %v receipt failed", msg.Id())
 		}
@@ -240,3 +244,35 @@ func test_Schedule(t *testing.T, sim *simulated.Backend) {
 		t.Log("execution resp: ", resp)
 	}
 }
+
+func Test_ScheduleMsg_MaxRuns(t *testing.T) {
+	sim := helper.SetUpClient(t)
+	defer sim.Close()
+	client := sim.Client()
+
+	req := message.AssignMessageId(&message.Request{
+		From:     helper.Addr1,
+		To:       &helper.Addr2,
+		Interval: 500 * time.Millisecond,
+		MaxRuns:  3,
+	})
+	client.ScheduleMsg(req)
+
+	time.Sleep(3 * time.Second)
+
+	root := req.Id()
+	children, _, err := client.ListMsgs(message.MsgFilter{Root: &root})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, 2, len(children))
+	for _, child := range children {
+		resp, ok := client.WaitMsgResponse(child.Id(), time.Second)
+		if !ok {
+			t.Fatal("wait msg response failed")
+		}
+		assert.Nil(t, resp.Err)
+	}
+	assert.Equal(t, uint64(1), children[1].Req.MaxRuns)
+}
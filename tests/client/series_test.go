@@ -0,0 +1,120 @@
+package client_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ivanzzeth/ethclient/message"
+	"github.com/ivanzzeth/ethclient/tests/helper"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Series(t *testing.T) {
+	sim := helper.SetUpClient(t)
+	defer sim.Close()
+	client := sim.Client()
+
+	req := message.AssignMessageId(&message.Request{
+		From:     helper.Addr1,
+		To:       &helper.Addr2,
+		Interval: 500 * time.Millisecond,
+	})
+	client.ScheduleMsg(req)
+
+	root := req.Id()
+	countMsgs := func() int {
+		msgs, _, err := client.ListMsgs(message.MsgFilter{Root: &root, Limit: 100})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return len(msgs)
+	}
+
+	time.Sleep(1200 * time.Millisecond)
+
+	err := client.PauseSeries(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(600 * time.Millisecond)
+
+	paused := countMsgs()
+	time.Sleep(2 * time.Second)
+	assert.Equal(t, paused, countMsgs(), "no msgs created while series paused")
+
+	series, err := client.ListSeries()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 1, len(series))
+	assert.Equal(t, root, series[0].RootId)
+	assert.Equal(t, message.SeriesStatusPaused, series[0].Status)
+
+	err = client.ResumeSeries(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(1500 * time.Millisecond)
+	assert.Greater(t, countMsgs(), paused, "msgs created after series resumed")
+
+	err = client.StopSeries(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Error(t, client.ResumeSeries(root), "stopped series could not be resumed")
+
+	stopped := countMsgs()
+	time.Sleep(1500 * time.Millisecond)
+	assert.Equal(t, stopped, countMsgs(), "no msgs created after series stopped")
+
+	msgs, _, err := client.ListMsgs(message.MsgFilter{Root: &root, Status: []message.MessageStatus{message.MessageStatusCancelled}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 1, len(msgs), "pending msg of stopped series cancelled")
+
+	s, err := client.GetSeries(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, message.SeriesStatusStopped, s.Status)
+}
+
+func Test_Series_PauseBeforeFirstRun(t *testing.T) {
+	sim := helper.SetUpClient(t)
+	defer sim.Close()
+	client := sim.Client()
+
+	req := message.AssignMessageId(&message.Request{
+		From:      helper.Addr1,
+		To:        &helper.Addr2,
+		Interval:  500 * time.Millisecond,
+		StartTime: time.Now().Add(time.Second).UnixNano(),
+	})
+	_, err := client.SubmitMsg(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	root := req.Id()
+	_, err = client.GetSeries(root)
+	assert.Nil(t, err, "series registered once submitted")
+
+	err = client.PauseSeries(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	time.Sleep(2 * time.Second)
+
+	msg, err := client.GetMsg(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Nil(t, msg.Resp, "first run waits for resuming")
+
+	_, err = client.GetSeries(common.HexToHash("0x1"))
+	assert.True(t, errors.Is(err, message.ErrSeriesNotFound))
+}
@@ -0,0 +1,174 @@
+package client_test
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ivanzzeth/ethclient/contracts"
+	"github.com/ivanzzeth/ethclient/message"
+	"github.com/ivanzzeth/ethclient/tests/helper"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_SimulationWithOverrides(t *testing.T) {
+	sim := helper.SetUpClient(t)
+	defer sim.Close()
+	client := sim.Client()
+	ctx := context.Background()
+
+	contractAddr, _, _ := helper.DeployTestContract(t, ctx, sim)
+	contractAbi := contracts.GetTestContractABI()
+
+	// the counter was increased by msgs not mined yet
+	data, err := client.NewMethodData(contractAbi, "counter")
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := message.AssignMessageId(&message.Request{
+		From:            helper.Addr1,
+		To:              &contractAddr,
+		Data:            data,
+		SimulationOn:    true,
+		SimulationBlock: big.NewInt(int64(rpc.LatestBlockNumber)),
+		SimulationOverrides: map[common.Address]message.AccountOverride{
+			contractAddr: {StateDiff: map[common.Hash]common.Hash{{}: common.BigToHash(big.NewInt(5))}},
+		},
+	})
+	client.ScheduleMsg(req)
+
+	resp, ok := client.WaitMsgResponse(req.Id(), 5*time.Second)
+	if !ok {
+		t.Fatal("wait msg response failed")
+	}
+	assert.Nil(t, resp.Err)
+	assert.NotNil(t, resp.Tx, "msg sent after simulation")
+	if assert.NotNil(t, resp.Simulation) {
+		assert.Equal(t, common.BigToHash(big.NewInt(5)).Bytes(), resp.Simulation.ReturnData)
+		assert.Empty(t, resp.Simulation.Revert)
+	}
+
+	// reverted in simulation
+	data, err = client.NewMethodData(contractAbi, "testReverted", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req = message.AssignMessageId(&message.Request{
+		From:         helper.Addr1,
+		To:           &contractAddr,
+		Data:         data,
+		SimulationOn: true,
+	})
+	client.ScheduleMsg(req)
+
+	resp, ok = client.WaitMsgResponse(req.Id(), 5*time.Second)
+	if !ok {
+		t.Fatal("wait msg response failed")
+	}
+	assert.NotNil(t, resp.Err)
+	assert.Nil(t, resp.Tx, "msg not sent if simulation failed")
+	if assert.NotNil(t, resp.Simulation) {
+		assert.Contains(t, resp.Simulation.Revert, "TestRevert(uint256 a, uint256 b)(1,2)")
+	}
+}
+
+func Test_SimulateChain(t *testing.T) {
+	sim := helper.SetUpClient(t)
+	defer sim.Close()
+	client := sim.Client()
+	ctx := context.Background()
+
+	contractAddr, _, _ := helper.DeployTestContract(t, ctx, sim)
+	contractAbi := contracts.GetTestContractABI()
+
+	newReq := func(method string, args ...interface{}) message.Request {
+		data, err := client.NewMethodData(contractAbi, method, args...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return message.Request{From: helper.Addr1, To: &contractAddr, Data: data}
+	}
+
+	reqs := []message.Request{
+		newReq("testFunc1", "a", big.NewInt(1), []byte{}),
+		newReq("testFunc1", "b", big.NewInt(2), []byte{}),
+		newReq("counter"),
+		newReq("testReverted", true),
+		newReq("counter"),
+	}
+
+	simulations, err := client.SimulateChain(ctx, reqs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !assert.Len(t, simulations, len(reqs)) {
+		return
+	}
+
+	for i, s := range simulations {
+		assert.NotZero(t, s.GasUsed, "gas used of req %d", i)
+	}
+	assert.Empty(t, simulations[0].Revert)
+	assert.Empty(t, simulations[1].Revert)
+	assert.Equal(t, common.BigToHash(big.NewInt(2)).Bytes(), simulations[2].ReturnData, "counter increased by previous reqs")
+	assert.Contains(t, simulations[3].Revert, "TestRevert(uint256 a, uint256 b)(1,2)")
+	assert.Equal(t, common.BigToHash(big.NewInt(2)).Bytes(), simulations[4].ReturnData, "reverted req changes nothing")
+
+	// nothing changed on chain
+	ret, err := client.CallMsg(ctx, newReq("counter"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, common.BigToHash(big.NewInt(0)).Bytes(), ret)
+
+	// tags are forked by their numbers
+	reqs[0].SimulationBlock = big.NewInt(int64(rpc.LatestBlockNumber))
+	simulations, err = client.SimulateChain(ctx, reqs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	latest, err := client.BlockNumber(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, new(big.Int).SetUint64(latest), simulations[0].BlockNumber)
+
+	// msgs in flight are seen by the pending state, but not by the latest block
+	inFlight := newReq("testFunc1", "c", big.NewInt(3), []byte{})
+	client.ScheduleMsg(message.AssignMessageId(&inFlight))
+	resp, ok := client.WaitMsgResponse(inFlight.Id(), 5*time.Second)
+	if !ok {
+		t.Fatal("wait msg response failed")
+	}
+	if resp.Err != nil {
+		t.Fatal(resp.Err)
+	}
+
+	for _, block := range []*big.Int{nil, big.NewInt(int64(rpc.PendingBlockNumber))} {
+		reqs[0].SimulationBlock = block
+		simulations, err = client.SimulateChain(ctx, reqs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, common.BigToHash(big.NewInt(3)).Bytes(), simulations[2].ReturnData, "counter increased by msg in flight")
+	}
+
+	reqs[0].SimulationBlock = big.NewInt(int64(rpc.LatestBlockNumber))
+	simulations, err = client.SimulateChain(ctx, reqs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, common.BigToHash(big.NewInt(2)).Bytes(), simulations[2].ReturnData)
+
+	// not seen once mined
+	sim.CommitAndExpectTx(resp.Tx.Hash())
+	reqs[0].SimulationBlock = nil
+	simulations, err = client.SimulateChain(ctx, reqs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, common.BigToHash(big.NewInt(3)).Bytes(), simulations[2].ReturnData)
+}
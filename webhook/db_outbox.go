@@ -0,0 +1,63 @@
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/ethdb"
+)
+
+var _ Outbox = &DBOutbox{}
+
+// DBOutbox keeps deliveries in the embedded key-value database, e.g. pebble or leveldb.
+type DBOutbox struct {
+	chainId *big.Int
+	db      ethdb.KeyValueStore
+}
+
+func NewDBOutbox(chainId *big.Int, db ethdb.KeyValueStore) *DBOutbox {
+	return &DBOutbox{
+		chainId: chainId,
+		db:      db,
+	}
+}
+
+func (o *DBOutbox) Put(d Delivery) error {
+	value, err := json.Marshal(d)
+	if err != nil {
+		return err
+	}
+
+	return o.db.Put(o.key(d.Id), value)
+}
+
+func (o *DBOutbox) Delete(id string) error {
+	return o.db.Delete(o.key(id))
+}
+
+func (o *DBOutbox) List() (list []Delivery, err error) {
+	it := o.db.NewIterator(o.prefix(), nil)
+	defer it.Release()
+
+	for it.Next() {
+		var d Delivery
+		err = json.Unmarshal(it.Value(), &d)
+		if err != nil {
+			return nil, err
+		}
+
+		list = append(list, d)
+	}
+
+	sortDeliveries(list)
+	return list, it.Error()
+}
+
+func (o *DBOutbox) prefix() []byte {
+	return []byte(fmt.Sprintf("webhook-outbox-chain-%s-", o.chainId.String()))
+}
+
+func (o *DBOutbox) key(id string) []byte {
+	return append(o.prefix(), id...)
+}
@@ -0,0 +1,33 @@
+package webhook
+
+import "sync"
+
+var _ Outbox = &MemoryOutbox{}
+
+type MemoryOutbox struct {
+	deliveries sync.Map
+}
+
+func NewMemoryOutbox() *MemoryOutbox {
+	return &MemoryOutbox{}
+}
+
+func (o *MemoryOutbox) Put(d Delivery) error {
+	o.deliveries.Store(d.Id, d)
+	return nil
+}
+
+func (o *MemoryOutbox) Delete(id string) error {
+	o.deliveries.Delete(id)
+	return nil
+}
+
+func (o *MemoryOutbox) List() (list []Delivery, err error) {
+	o.deliveries.Range(func(_, value any) bool {
+		list = append(list, value.(Delivery))
+		return true
+	})
+
+	sortDeliveries(list)
+	return list, nil
+}
@@ -0,0 +1,355 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/google/uuid"
+	"github.com/ivanzzeth/ethclient/message"
+)
+
+const (
+	// SignatureHeader is "sha256=" followed by the hex HMAC-SHA256 of the body with the secret of endpoint.
+	SignatureHeader = "X-Ethclient-Signature"
+	// DeliveryHeader is the id of delivery, which is the same between retries.
+	DeliveryHeader = "X-Ethclient-Delivery"
+
+	DefaultMaxAttempts = 10
+	DefaultMinBackoff  = time.Second
+	DefaultMaxBackoff  = 10 * time.Minute
+	DefaultTimeout     = 10 * time.Second
+
+	// flushInterval is how often the outbox is checked for deliveries due to retry.
+	flushInterval = time.Second
+)
+
+// Endpoint receives payloads of msg updates matched by the filter, e.g. statuses, senders and labels.
+// The URL identifies the endpoint, so it should be unique.
+type Endpoint struct {
+	URL    string
+	Secret []byte
+	Filter message.MsgFilter
+}
+
+// Payload is posted to endpoints as JSON once a msg was updated.
+// A msg may be posted more than once for the same status, e.g. its receipt was updated after being on-chain.
+type Payload struct {
+	MsgId     common.Hash
+	OldStatus message.MessageStatus
+	Status    message.MessageStatus
+	Msg       message.Message
+	Timestamp int64 // unix nano
+}
+
+var _ message.MessageObserver = (*Notifier)(nil)
+
+// Notifier posts signed payloads of msg updates to endpoints.
+// Payloads are kept in the outbox until posted, and retried with exponential backoff on failures.
+// Endpoints are posted concurrently, each in order of creation, and an endpoint failed backs off
+// as a whole, so that a slow or down endpoint doesn't hold up others.
+type Notifier struct {
+	endpoints   []Endpoint
+	outbox      Outbox
+	httpClient  *http.Client
+	maxAttempts int
+	minBackoff  time.Duration
+	maxBackoff  time.Duration
+
+	lock   sync.Mutex // guards states
+	states map[string]*endpointState
+	wake   chan struct{}
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// endpointState tracks deliveries of an endpoint.
+type endpointState struct {
+	busy     bool  // deliveries are being posted
+	failures int   // consecutive failures
+	retryAt  int64 // unix nano, backing off until
+}
+
+func NewNotifier(outbox Outbox, endpoints ...Endpoint) *Notifier {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	n := &Notifier{
+		endpoints:   endpoints,
+		outbox:      outbox,
+		httpClient:  &http.Client{Timeout: DefaultTimeout},
+		maxAttempts: DefaultMaxAttempts,
+		minBackoff:  DefaultMinBackoff,
+		maxBackoff:  DefaultMaxBackoff,
+		states:      make(map[string]*endpointState),
+		wake:        make(chan struct{}, 1),
+		ctx:         ctx,
+		cancel:      cancel,
+	}
+
+	go n.run()
+
+	return n
+}
+
+func (n *Notifier) SetHTTPClient(client *http.Client) {
+	n.httpClient = client
+}
+
+// SetMaxAttempts sets how many times a payload is posted before being dropped.
+func (n *Notifier) SetMaxAttempts(attempts int) {
+	n.maxAttempts = attempts
+}
+
+// SetBackoff sets the delay before the first retry, which is doubled for every retry up to max.
+func (n *Notifier) SetBackoff(min, max time.Duration) {
+	n.minBackoff = min
+	n.maxBackoff = max
+}
+
+func (n *Notifier) Close() {
+	n.cancel()
+}
+
+func (n *Notifier) OnMsgUpdated(oldStatus, newStatus message.MessageStatus, msg message.Message) {
+	now := time.Now().UnixNano()
+
+	for _, endpoint := range n.endpoints {
+		if !endpoint.Filter.Match(msg) {
+			continue
+		}
+
+		body, err := json.Marshal(Payload{
+			MsgId:     msg.Id(),
+			OldStatus: oldStatus,
+			Status:    newStatus,
+			Msg:       msg,
+			Timestamp: now,
+		})
+		if err != nil {
+			log.Error("encode webhook payload failed", "msgId", msg.Id().Hex(), "err", err)
+			continue
+		}
+
+		err = n.outbox.Put(Delivery{
+			Id:          uuid.NewString(),
+			URL:         endpoint.URL,
+			Body:        body,
+			NextAttempt: now,
+			CreatedAt:   now,
+		})
+		if err != nil {
+			log.Error("put webhook delivery to outbox failed", "msgId", msg.Id().Hex(), "url", endpoint.URL, "err", err)
+		}
+	}
+
+	select {
+	case n.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (n *Notifier) run() {
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-n.ctx.Done():
+			return
+		case <-n.wake:
+		case <-ticker.C:
+		}
+
+		err := n.flush()
+		if err != nil {
+			log.Warn("flush webhook outbox failed", "err", err)
+		}
+	}
+}
+
+// flush posts deliveries due to endpoints which are neither busy nor backing off, without waiting for them.
+func (n *Notifier) flush() error {
+	now := time.Now().UnixNano()
+
+	// endpoints are taken before listing, so that deliveries listed are not posted by others meanwhile
+	taken := make(map[string]bool)
+	n.lock.Lock()
+	for _, endpoint := range n.endpoints {
+		state := n.state(endpoint.URL)
+		if !state.busy && state.retryAt <= now {
+			state.busy = true
+			taken[endpoint.URL] = true
+		}
+	}
+	n.lock.Unlock()
+
+	deliveries, err := n.outbox.List()
+	if err != nil {
+		n.release(taken)
+		return err
+	}
+
+	due := make(map[string][]Delivery)
+	for _, d := range deliveries {
+		if d.NextAttempt > now {
+			continue
+		}
+
+		if _, ok := n.endpoint(d.URL); !ok {
+			log.Warn("drop webhook delivery of unknown endpoint", "id", d.Id, "url", d.URL)
+			err = n.outbox.Delete(d.Id)
+			if err != nil {
+				log.Warn("delete webhook delivery failed", "id", d.Id, "err", err)
+			}
+			continue
+		}
+
+		if taken[d.URL] {
+			due[d.URL] = append(due[d.URL], d)
+		}
+	}
+
+	for url := range taken {
+		if len(due[url]) == 0 {
+			n.release(map[string]bool{url: true})
+			continue
+		}
+
+		go n.deliverAll(url, due[url])
+	}
+
+	return nil
+}
+
+// deliverAll posts deliveries of the endpoint in order until one failed, then the endpoint backs off.
+func (n *Notifier) deliverAll(url string, deliveries []Delivery) {
+	defer n.release(map[string]bool{url: true})
+
+	for _, d := range deliveries {
+		select {
+		case <-n.ctx.Done():
+			return
+		default:
+		}
+
+		posted, err := n.deliver(d)
+		if err != nil {
+			log.Warn("update webhook delivery failed", "id", d.Id, "url", url, "err", err)
+		}
+
+		n.lock.Lock()
+		state := n.state(url)
+		if posted {
+			state.failures = 0
+		} else {
+			state.failures++
+			state.retryAt = time.Now().Add(n.backoff(state.failures)).UnixNano()
+		}
+		n.lock.Unlock()
+
+		if !posted {
+			return
+		}
+	}
+}
+
+// deliver posts the delivery, which is deleted from the outbox once posted or dropped,
+// otherwise it's put back for retrying.
+func (n *Notifier) deliver(d Delivery) (posted bool, err error) {
+	endpoint, _ := n.endpoint(d.URL)
+
+	err = n.post(endpoint, d)
+	if err == nil {
+		return true, n.outbox.Delete(d.Id)
+	}
+
+	d.Attempts++
+	if d.Attempts >= n.maxAttempts {
+		log.Error("drop webhook delivery after max attempts", "id", d.Id, "url", d.URL, "attempts", d.Attempts, "err", err)
+		return false, n.outbox.Delete(d.Id)
+	}
+
+	log.Warn("post webhook failed", "id", d.Id, "url", d.URL, "attempts", d.Attempts, "err", err)
+
+	d.NextAttempt = time.Now().Add(n.backoff(d.Attempts)).UnixNano()
+	return false, n.outbox.Put(d)
+}
+
+// state returns the state of the endpoint, it must be called with the lock held.
+func (n *Notifier) state(url string) *endpointState {
+	state, ok := n.states[url]
+	if !ok {
+		state = &endpointState{}
+		n.states[url] = state
+	}
+
+	return state
+}
+
+func (n *Notifier) release(urls map[string]bool) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	for url := range urls {
+		n.state(url).busy = false
+	}
+}
+
+func (n *Notifier) post(endpoint Endpoint, d Delivery) error {
+	req, err := http.NewRequestWithContext(n.ctx, http.MethodPost, endpoint.URL, bytes.NewReader(d.Body))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(DeliveryHeader, d.Id)
+	req.Header.Set(SignatureHeader, Sign(endpoint.Secret, d.Body))
+
+	resp, err := n.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status code: %v", resp.StatusCode)
+	}
+
+	return nil
+}
+
+func (n *Notifier) endpoint(url string) (Endpoint, bool) {
+	for _, endpoint := range n.endpoints {
+		if endpoint.URL == url {
+			return endpoint, true
+		}
+	}
+
+	return Endpoint{}, false
+}
+
+func (n *Notifier) backoff(attempts int) time.Duration {
+	backoff := n.minBackoff
+	for i := 1; i < attempts && backoff < n.maxBackoff; i++ {
+		backoff *= 2
+	}
+
+	return min(backoff, n.maxBackoff)
+}
+
+// Sign returns the value of SignatureHeader, receivers should verify it with hmac.Equal.
+func Sign(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
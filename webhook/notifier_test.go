@@ -0,0 +1,158 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"encoding/json"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethdb/memorydb"
+	"github.com/ivanzzeth/ethclient/message"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Notifier(t *testing.T) {
+	secret := []byte("secret")
+
+	var (
+		lock     sync.Mutex
+		failures = 2
+		received []Payload
+		ids      []string
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Error(err)
+		}
+
+		if !hmac.Equal([]byte(Sign(secret, body)), []byte(r.Header.Get(SignatureHeader))) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		lock.Lock()
+		defer lock.Unlock()
+
+		ids = append(ids, r.Header.Get(DeliveryHeader))
+		if failures > 0 {
+			failures--
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		var payload Payload
+		err = json.Unmarshal(body, &payload)
+		if err != nil {
+			t.Error(err)
+		}
+		received = append(received, payload)
+	}))
+	defer server.Close()
+
+	sender := common.HexToAddress("0x1")
+	outbox := NewDBOutbox(big.NewInt(1337), memorydb.New())
+	notifier := NewNotifier(outbox, Endpoint{
+		URL:    server.URL,
+		Secret: secret,
+		Filter: message.MsgFilter{
+			Status: []message.MessageStatus{message.MessageStatusOnChain},
+			From:   []common.Address{sender},
+		},
+	})
+	notifier.SetBackoff(10*time.Millisecond, 100*time.Millisecond)
+	defer notifier.Close()
+
+	matched := message.AssignMessageId(&message.Request{From: sender})
+	notifier.OnMsgUpdated(message.MessageStatusInflight, message.MessageStatusOnChain,
+		message.Message{Req: matched, Status: message.MessageStatusOnChain})
+	notifier.OnMsgUpdated(message.MessageStatusNonceAssigned, message.MessageStatusInflight,
+		message.Message{Req: matched, Status: message.MessageStatusInflight})
+
+	other := message.AssignMessageId(&message.Request{From: common.HexToAddress("0x2")})
+	notifier.OnMsgUpdated(message.MessageStatusInflight, message.MessageStatusOnChain,
+		message.Message{Req: other, Status: message.MessageStatusOnChain})
+
+	assert.Eventually(t, func() bool {
+		lock.Lock()
+		defer lock.Unlock()
+		return len(received) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+
+	lock.Lock()
+	defer lock.Unlock()
+	assert.Equal(t, matched.Id(), received[0].MsgId)
+	assert.Equal(t, message.MessageStatusInflight, received[0].OldStatus)
+	assert.Equal(t, message.MessageStatusOnChain, received[0].Status)
+	assert.Equal(t, []string{ids[0], ids[0], ids[0]}, ids, "retried with the same delivery id")
+
+	deliveries, err := outbox.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Empty(t, deliveries)
+}
+
+func Test_NotifierBackoff(t *testing.T) {
+	n := &Notifier{minBackoff: time.Second, maxBackoff: 5 * time.Second}
+
+	assert.Equal(t, time.Second, n.backoff(1))
+	assert.Equal(t, 2*time.Second, n.backoff(2))
+	assert.Equal(t, 4*time.Second, n.backoff(3))
+	assert.Equal(t, 5*time.Second, n.backoff(4))
+	assert.Equal(t, 5*time.Second, n.backoff(100))
+}
+
+func Test_Notifier_SlowEndpoint(t *testing.T) {
+	release := make(chan struct{})
+	var slowPosts atomic.Int32
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		slowPosts.Add(1)
+		<-release
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer slow.Close()
+	defer close(release)
+
+	var fastPosts atomic.Int32
+	fast := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fastPosts.Add(1)
+	}))
+	defer fast.Close()
+
+	outbox := NewMemoryOutbox()
+	notifier := NewNotifier(outbox, Endpoint{URL: slow.URL}, Endpoint{URL: fast.URL})
+	notifier.SetBackoff(time.Minute, time.Minute)
+	defer notifier.Close()
+
+	for i := 0; i < 3; i++ {
+		req := message.AssignMessageId(&message.Request{})
+		notifier.OnMsgUpdated(message.MessageStatusInflight, message.MessageStatusOnChain,
+			message.Message{Req: req, Status: message.MessageStatusOnChain})
+	}
+
+	// the fast endpoint is not held up by the slow one
+	assert.Eventually(t, func() bool {
+		return fastPosts.Load() == 3
+	}, 3*time.Second, 10*time.Millisecond)
+	assert.Equal(t, int32(1), slowPosts.Load(), "deliveries of the busy endpoint wait")
+
+	release <- struct{}{}
+
+	// the endpoint failed backs off as a whole
+	time.Sleep(1500 * time.Millisecond)
+	assert.Equal(t, int32(1), slowPosts.Load())
+
+	deliveries, err := outbox.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, deliveries, 3)
+}
@@ -0,0 +1,38 @@
+package webhook
+
+import (
+	"slices"
+	"strings"
+)
+
+// Delivery is a payload waiting for being posted to the endpoint.
+type Delivery struct {
+	Id          string
+	URL         string
+	Body        []byte
+	Attempts    int
+	NextAttempt int64 // unix nano
+	CreatedAt   int64 // unix nano
+}
+
+// Outbox keeps deliveries until they were posted, so that they survive restarts.
+type Outbox interface {
+	// Put adds the delivery or replaces the one with the same id.
+	Put(d Delivery) error
+	Delete(id string) error
+	// List returns all deliveries in order of creation.
+	List() ([]Delivery, error)
+}
+
+func sortDeliveries(list []Delivery) {
+	slices.SortFunc(list, func(a, b Delivery) int {
+		if a.CreatedAt != b.CreatedAt {
+			if a.CreatedAt < b.CreatedAt {
+				return -1
+			}
+			return 1
+		}
+
+		return strings.Compare(a.Id, b.Id)
+	})
+}
@@ -0,0 +1,95 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	"github.com/go-redsync/redsync/v4/redis"
+)
+
+var _ Outbox = &RedisOutbox{}
+
+var (
+	// KEYS: outbox; ARGV: id, delivery
+	outboxPutScript = redis.NewScript(1, `return redis.call("HSET", KEYS[1], ARGV[1], ARGV[2])`)
+
+	// KEYS: outbox; ARGV: id
+	outboxDeleteScript = redis.NewScript(1, `return redis.call("HDEL", KEYS[1], ARGV[1])`)
+
+	// KEYS: outbox
+	outboxListScript = redis.NewScript(1, `return redis.call("HVALS", KEYS[1])`)
+)
+
+// RedisOutbox shares deliveries between processes,
+// which may post the same delivery more than once, so receivers should dedupe by the delivery id.
+type RedisOutbox struct {
+	chainId   *big.Int
+	redisPool redis.Pool
+}
+
+func NewRedisOutbox(chainId *big.Int, pool redis.Pool) *RedisOutbox {
+	return &RedisOutbox{
+		chainId:   chainId,
+		redisPool: pool,
+	}
+}
+
+func (o *RedisOutbox) Put(d Delivery) error {
+	conn, err := o.redisPool.Get(context.Background())
+	if err != nil {
+		return err
+	}
+
+	value, err := json.Marshal(d)
+	if err != nil {
+		return err
+	}
+
+	_, err = conn.Eval(outboxPutScript, o.key(), d.Id, string(value))
+	return err
+}
+
+func (o *RedisOutbox) Delete(id string) error {
+	conn, err := o.redisPool.Get(context.Background())
+	if err != nil {
+		return err
+	}
+
+	_, err = conn.Eval(outboxDeleteScript, o.key(), id)
+	return err
+}
+
+func (o *RedisOutbox) List() (list []Delivery, err error) {
+	conn, err := o.redisPool.Get(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	reply, err := conn.Eval(outboxListScript, o.key())
+	if err != nil {
+		return nil, err
+	}
+
+	values, _ := reply.([]interface{})
+	for _, v := range values {
+		value, _ := v.(string)
+
+		var d Delivery
+		err = json.Unmarshal([]byte(value), &d)
+		if err != nil {
+			return nil, err
+		}
+
+		list = append(list, d)
+	}
+
+	sortDeliveries(list)
+	return list, nil
+}
+
+// key is a hash of all deliveries.
+func (o *RedisOutbox) key() string {
+	return fmt.Sprintf("webhook-outbox-chain-%s", o.chainId.String())
+}